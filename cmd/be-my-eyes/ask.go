@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/prompts"
+	"github.com/fboucher/be-my-eyes/internal/qa"
+)
+
+// runAsk implements the ask command: one question about one video, typed or
+// taken from the prompt library
+func runAsk(args []string) int {
+	fs := flag.NewFlagSet("ask", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: be-my-eyes ask --video <id> [options] [question]")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	videoID := fs.String("video", "", "ID of the video to ask about")
+	templateName := fs.String("template", "", "prompt template to use instead of a question")
+	asJSON := fs.Bool("json", false, "print the answer as JSON")
+	refresh := fs.Bool("refresh", false, "ask the API even if the answer is cached")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	question := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if *videoID == "" || (question == "") == (*templateName == "") {
+		fmt.Fprintln(fs.Output(), "Error: give a video and either a question or --template")
+		fs.Usage()
+		return 2
+	}
+
+	cfg, apiClient, database, err := openBackends()
+	if err != nil {
+		exitWithSetupError(err)
+	}
+	defer database.Close()
+
+	video := models.Video{VideoID: *videoID}
+	if response, err := apiClient.GetVideos([]string{*videoID}); err == nil && len(response.Results) > 0 {
+		video = response.Results[0]
+	}
+
+	if *templateName != "" {
+		t, err := prompts.Get(database, *templateName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if question, err = prompts.Render(*t, video); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	opts := qa.Options{CacheTTL: cfg.AnswerCacheTTL(), Refresh: *refresh}
+	query, err := qa.AskVideo(context.Background(), apiClient, database, video, question, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(query); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if query.Error != nil && *query.Error != "" {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", *query.Error)
+		return 1
+	}
+	if query.Cached {
+		fmt.Fprintf(os.Stderr, "(cached answer from %s, use --refresh to ask again)\n", query.CreatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Println(query.Answer)
+	for _, clip := range query.VideoClips {
+		fmt.Printf("  %.1fs - %.1fs  %s\n", clip.StartTime, clip.EndTime, clip.Info)
+	}
+	return 0
+}
+
+// runTemplates implements the templates command managing the prompt library
+func runTemplates(args []string) int {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  be-my-eyes templates [list]")
+		fmt.Fprintln(os.Stderr, "  be-my-eyes templates save <name> <text>")
+		fmt.Fprintln(os.Stderr, "  be-my-eyes templates delete <name>")
+		fmt.Fprintln(os.Stderr, "\nTemplates use Go text/template syntax with the video metadata,")
+		fmt.Fprintln(os.Stderr, "e.g. {{.Title}}, {{.Duration}}, {{.Description}} and {{.VideoID}}.")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	database, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch {
+	case action == "list":
+		templates, err := prompts.List(database)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, t := range templates {
+			fmt.Printf("%-16s %s\n", t.Name, t.Text)
+		}
+
+	case action == "save" && len(args) >= 3:
+		if err := prompts.Save(database, args[1], strings.Join(args[2:], " ")); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Saved template %s\n", args[1])
+
+	case action == "delete" && len(args) == 2:
+		if err := database.DeletePromptTemplate(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Deleted template %s\n", args[1])
+
+	default:
+		usage()
+		return 2
+	}
+
+	return 0
+}
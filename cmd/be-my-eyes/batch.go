@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/qa"
+)
+
+// batchAnswer is one row of the batch-ask JSON output
+type batchAnswer struct {
+	VideoID    string             `json:"video_id"`
+	VideoTitle string             `json:"video_title"`
+	Status     string             `json:"status"`
+	Answer     string             `json:"answer,omitempty"`
+	VideoClips []models.VideoClip `json:"video_clips,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// runBatchAsk implements the batch-ask command: the same question asked about
+// every video listed in a file or on stdin, one video ID per line
+func runBatchAsk(args []string) int {
+	fs := flag.NewFlagSet("batch-ask", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: be-my-eyes batch-ask [options] <question>")
+		fmt.Fprintln(fs.Output(), "\nVideo IDs are read one per line; blank lines and lines starting with # are ignored.")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	file := fs.String("file", "-", "file with one video ID per line, - for stdin")
+	concurrency := fs.Int("concurrency", 0, "questions asked at the same time (default: max_concurrent_jobs)")
+	asJSON := fs.Bool("json", false, "print the answers as JSON")
+	refresh := fs.Bool("refresh", false, "ask the API even for cached answers")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	question := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if question == "" {
+		fs.Usage()
+		return 2
+	}
+
+	videoIDs, err := readVideoIDsFrom(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(videoIDs) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no video IDs given")
+		return 1
+	}
+
+	cfg, apiClient, database, err := openBackends()
+	if err != nil {
+		exitWithSetupError(err)
+	}
+	defer database.Close()
+
+	// Fetch titles; IDs the API doesn't know are still asked so their errors
+	// show up in the results
+	known := make(map[string]models.Video)
+	if response, err := apiClient.GetVideos(videoIDs); err == nil {
+		for _, v := range response.Results {
+			known[v.VideoID] = v
+		}
+	}
+	videos := make([]models.Video, len(videoIDs))
+	for i, id := range videoIDs {
+		if v, ok := known[id]; ok {
+			videos[i] = v
+		} else {
+			videos[i] = models.Video{VideoID: id}
+		}
+	}
+
+	limit := *concurrency
+	if limit <= 0 {
+		limit = cfg.JobLimit()
+	}
+
+	done := 0
+	opts := qa.Options{CacheTTL: cfg.AnswerCacheTTL(), Refresh: *refresh}
+	results := qa.AskMany(context.Background(), apiClient, database, videos, question, limit, opts, func(r qa.Result) {
+		done++
+		status, _ := resultStatus(r)
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s: %s\n", done, len(videos), qa.VideoTitle(r.Video), status)
+	})
+
+	failed := 0
+	answers := make([]batchAnswer, len(results))
+	for i, r := range results {
+		status, text := resultStatus(r)
+		answers[i] = batchAnswer{
+			VideoID:    r.Video.VideoID,
+			VideoTitle: qa.VideoTitle(r.Video),
+			Status:     status,
+		}
+		if status == "failed" {
+			failed++
+			answers[i].Error = text
+		} else {
+			answers[i].Answer = text
+			answers[i].VideoClips = r.Query.VideoClips
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(answers); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	} else {
+		printBatchTable(os.Stdout, answers)
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// resultStatus returns the status of a batch result and its answer, or its
+// error message when it failed
+func resultStatus(r qa.Result) (string, string) {
+	switch {
+	case r.Err != nil:
+		return "failed", r.Err.Error()
+	case r.Query.Error != nil && *r.Query.Error != "":
+		return "failed", *r.Query.Error
+	case r.Query.Cached:
+		return "cached", r.Query.Answer
+	default:
+		return "answered", r.Query.Answer
+	}
+}
+
+// printBatchTable prints the answers side by side, first line only
+func printBatchTable(w io.Writer, answers []batchAnswer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VIDEO\tID\tSTATUS\tANSWER")
+	for _, a := range answers {
+		text := a.Answer
+		if a.Status == "failed" {
+			text = a.Error
+		}
+		text = truncate(strings.SplitN(strings.TrimSpace(text), "\n", 2)[0], 100)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", a.VideoTitle, a.VideoID, a.Status, text)
+	}
+	tw.Flush()
+}
+
+// truncate shortens text to at most max characters, ending with "..." when
+// it was cut. It never splits a character.
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+// readVideoIDsFrom reads video IDs from a file, or from stdin for "-"
+func readVideoIDsFrom(path string) ([]string, error) {
+	if path == "-" {
+		return readVideoIDs(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open video ID file: %w", err)
+	}
+	defer f.Close()
+	return readVideoIDs(f)
+}
+
+// readVideoIDs reads one video ID per line, skipping blank lines, comments
+// and duplicates
+func readVideoIDs(r io.Reader) ([]string, error) {
+	var ids []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		id := strings.TrimSpace(scanner.Text())
+		if id == "" || strings.HasPrefix(id, "#") || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read video IDs: %w", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"a longer answer", 10, "a longe..."},
+		{"caméra à l'entrée", 10, "caméra ..."},
+		{"日本語のテキストです", 8, "日本語のテ..."},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.text, tt.max)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestPrintBatchTable(t *testing.T) {
+	var b bytes.Buffer
+	printBatchTable(&b, []batchAnswer{
+		{VideoID: "v1", VideoTitle: "Door", Status: "answered", Answer: "A person\nsecond line"},
+		{VideoID: "v2", VideoTitle: "Yard", Status: "failed", Error: strings.Repeat("é", 150)},
+	})
+
+	out := b.String()
+	if strings.Contains(out, "second line") {
+		t.Error("only the first line of an answer should be printed")
+	}
+	if !strings.Contains(out, strings.Repeat("é", 97)+"...") || strings.Contains(out, strings.Repeat("é", 98)) {
+		t.Errorf("long error not cut at 100 characters:\n%s", out)
+	}
+	if !utf8.ValidString(out) {
+		t.Error("a character was split")
+	}
+}
+
+func TestReadVideoIDs(t *testing.T) {
+	ids, err := readVideoIDs(strings.NewReader("v1\n\n# comment\n  v2  \nv1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(ids, ",") != "v1,v2" {
+		t.Errorf("ids = %v, want [v1 v2]", ids)
+	}
+}
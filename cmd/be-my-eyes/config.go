@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/fboucher/be-my-eyes/internal/config"
+)
+
+// runConfig implements the config command showing the configuration layers
+func runConfig(args []string) int {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  be-my-eyes config show               Settings of the config file")
+		fmt.Fprintln(os.Stderr, "  be-my-eyes config show --effective   Every setting after defaults, file, BME_* and --set, with its source")
+	}
+
+	if len(args) == 0 || args[0] != "show" {
+		usage()
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+	fs.Usage = usage
+	effective := fs.Bool("effective", false, "show the effective configuration")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if !*effective {
+		return showConfigFile()
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+	for _, s := range cfg.Settings() {
+		fmt.Fprintf(w, "%s\t%q\t%s\n", s.Key, s.Value, s.Source)
+	}
+	w.Flush()
+	return 0
+}
+
+// showConfigFile prints the config file layer, without the API key
+func showConfigFile() int {
+	path, err := config.FilePath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if cfg.APIKey != "" {
+		cfg.APIKey = "[REDACTED]"
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("# %s\n%s\n", path, data)
+	return 0
+}
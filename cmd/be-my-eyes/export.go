@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fboucher/be-my-eyes/internal/export"
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/wallclock"
+)
+
+// runExport implements the export command: the clips of saved queries as
+// subtitles, chapters or cuts
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: be-my-eyes export --query <id>[,<id>...] --format <format> [options]")
+		fmt.Fprintln(fs.Output(), "\nFormats:")
+		for _, f := range export.Formats {
+			fmt.Fprintf(fs.Output(), "  %-12s %s\n", f.Format, f.Description)
+		}
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	queryIDs := fs.String("query", "", "IDs of the saved queries (shown in the TUI details), comma-separated; several only for ffmpeg and edl")
+	formatName := fs.String("format", "srt", "export format")
+	output := fs.String("o", "-", "output file, - for stdout")
+	padding := fs.Float64("padding", 0, "seconds added before and after each clip (ffmpeg, edl)")
+	merge := fs.Bool("merge", false, "merge clips that overlap once padded (ffmpeg, edl)")
+	source := fs.String("source", "url", "video to cut: url for the library video URL, or a local file path (ffmpeg, edl)")
+	fps := fs.Float64("fps", 0, "EDL timecode frame rate (default: the video frame rate, or 30)")
+	wallClock := fs.Bool("wallclock", false, "prefix cue and chapter text with the time of day of the clip, for videos that record their start (srt, vtt, chapters, ffmetadata)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	format, err := export.ParseFormat(*formatName)
+	if err == nil && *queryIDs == "" {
+		err = fmt.Errorf("--query is required")
+	}
+	var ids []int
+	if err == nil {
+		ids, err = parseQueryIDs(*queryIDs)
+	}
+	if err != nil {
+		fmt.Fprintf(fs.Output(), "Error: %v\n", err)
+		fs.Usage()
+		return 2
+	}
+
+	cfg, apiClient, database, err := openBackends()
+	if err != nil {
+		exitWithSetupError(err)
+	}
+	defer database.Close()
+	loc, _ := cfg.Location() // validated by openBackends
+
+	var sources []export.Source
+	videos := make(map[string]models.Video)
+	for _, id := range ids {
+		query, err := database.GetQueryByID(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		// The duration bounds the clips; without it they are only checked
+		// for consistency
+		video, ok := videos[query.VideoID]
+		if !ok {
+			if response, err := apiClient.GetVideos([]string{query.VideoID}); err == nil && len(response.Results) > 0 {
+				video = response.Results[0]
+			} else {
+				fmt.Fprintf(os.Stderr, "Warning: could not get video %s, clip times are not checked against its duration\n", query.VideoID)
+				video = models.Video{VideoID: query.VideoID}
+			}
+			videos[query.VideoID] = video
+		}
+
+		path := video.URL
+		if *source != "url" {
+			path = *source
+		}
+		start, _ := wallclock.Start(video.Metadata, loc)
+		sources = append(sources, export.Source{
+			Path:     path,
+			Title:    query.VideoTitle,
+			Duration: video.Metadata.Duration,
+			FPS:      video.Metadata.AvgFPS,
+			Start:    start,
+			Clips:    query.VideoClips,
+		})
+	}
+
+	if *source != "url" && len(videos) > 1 {
+		fmt.Fprintf(os.Stderr, "Error: --source can only name a local file when every query is about the same video\n")
+		return 2
+	}
+
+	content, count, err := export.Export(format, sources, export.Options{
+		Padding:   *padding,
+		Merge:     *merge,
+		FrameRate: *fps,
+		WallClock: *wallClock,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *output == "-" {
+		fmt.Print(content)
+		return 0
+	}
+
+	perm := os.FileMode(0644)
+	if format == export.FFmpegScript {
+		perm = 0755
+	}
+	if err := os.WriteFile(*output, []byte(content), perm); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write %s: %v\n", *output, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d clips to %s\n", count, *output)
+	return 0
+}
+
+// parseQueryIDs parses a comma-separated list of query IDs
+func parseQueryIDs(s string) ([]int, error) {
+	var ids []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid query ID %q", field)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("--query is required")
+	}
+	return ids, nil
+}
@@ -1,21 +1,54 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/cassette"
 	"github.com/fboucher/be-my-eyes/internal/config"
 	"github.com/fboucher/be-my-eyes/internal/db"
+	"github.com/fboucher/be-my-eyes/internal/logging"
 	"github.com/fboucher/be-my-eyes/internal/ui"
 	"github.com/fboucher/be-my-eyes/internal/version"
 )
 
+// overrides are the settings given with --set before the command, the
+// highest configuration layer
+var overrides = map[string]string{}
+
 func main() {
+	args, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Subcommands
+	if len(args) > 0 {
+		switch args[0] {
+		case "ask":
+			os.Exit(runAsk(args[1:]))
+		case "batch-ask":
+			os.Exit(runBatchAsk(args[1:]))
+		case "config":
+			os.Exit(runConfig(args[1:]))
+		case "export":
+			os.Exit(runExport(args[1:]))
+		case "mcp":
+			os.Exit(runMCP(args[1:]))
+		case "serve":
+			os.Exit(runServe(args[1:]))
+		case "templates":
+			os.Exit(runTemplates(args[1:]))
+		}
+	}
+
 	// Lightweight flag handling for version/help before doing any setup
-	for _, arg := range os.Args[1:] {
+	for _, arg := range args {
 		switch arg {
 		case "--version", "-v":
 			fmt.Printf("be-my-eyes %s\n", version.Version)
@@ -30,50 +63,185 @@ func main() {
 		}
 	}
 
-	// Load configuration and ensure API key is available
-	apiKey, err := config.EnsureAPIKey()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		fmt.Fprintf(os.Stderr, "\nPlease set your Reka API key:\n")
-		fmt.Fprintf(os.Stderr, "  export REKA_API_KEY=your_api_key_here\n")
-		fmt.Fprintf(os.Stderr, "\nOr add it to ~/.config/be-my-eyes/config.json:\n")
-		fmt.Fprintf(os.Stderr, "  {\"api_key\": \"your_api_key_here\"}\n")
-		os.Exit(1)
+	cfg, apiClient, database, err := openBackends()
+	if errors.Is(err, config.ErrNoAPIKey) {
+		// The TUI asks for the key on its setup screen
+		database, err = openDatabase(cfg)
 	}
-
-	// Initialize API client
-	apiClient := api.NewClient(apiKey)
-
-	// Open database
-	database, err := db.Open()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
-		os.Exit(1)
+		exitWithSetupError(err)
 	}
-	defer database.Close()
 
-	// Create TUI model
-	model := ui.NewModel(apiClient, database)
+	// Create TUI model, which owns the database from here: switching
+	// profiles replaces it
+	output := ui.NewOutput(os.Stdout)
+	model := ui.NewModel(apiClient, database, cfg).WithOutput(output)
 
 	// Create program with alternate screen buffer (clears on exit)
 	p := tea.NewProgram(
 		model,
 		tea.WithAltScreen(),       // Use alternate screen buffer (clears on exit)
 		tea.WithMouseCellMotion(), // Enable mouse support
+		tea.WithOutput(output),    // Shared with OSC 52 copies
 	)
 
 	// Run the program
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if m, ok := final.(ui.Model); ok {
+		m.Close()
+	} else {
+		model.Close()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// parseGlobalFlags reads the options given before the command and returns
+// the remaining arguments
+func parseGlobalFlags(args []string) ([]string, error) {
+	for len(args) > 0 {
+		name, value, hasValue := strings.Cut(args[0], "=")
+		if name == "--debug" && !hasValue {
+			overrides["log.level"] = logging.LevelDebug
+			args = args[1:]
+			continue
+		}
+		switch name {
+		case "--set", "--config", "--db", "--profile", "--record", "--replay":
+		default:
+			return args, nil
+		}
+		if !hasValue {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("%s needs a value", name)
+			}
+			value, args = args[1], args[1:]
+		}
+		args = args[1:]
+
+		switch name {
+		case "--set":
+			key, v, ok := strings.Cut(value, "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("--set needs key=value, got %q", value)
+			}
+			overrides[key] = v
+		case "--config":
+			config.SetFilePath(value)
+		case "--db":
+			overrides["storage.db_path"] = value
+		case "--profile":
+			overrides["profile"] = value
+		case "--record":
+			overrides["api.cassette"] = value
+			overrides["api.cassette_mode"] = cassette.Record
+		case "--replay":
+			overrides["api.cassette"] = value
+			overrides["api.cassette_mode"] = cassette.Replay
+		}
+	}
+	return args, nil
+}
+
+// loadConfig resolves the effective configuration and reports its warnings
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Resolve(overrides)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range cfg.Warnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	return cfg, nil
+}
+
+// openBackends loads the configuration, then creates the API client and opens
+// the history database. Without an API key, the configuration is still
+// returned with ErrNoAPIKey.
+func openBackends() (*config.Config, *api.Client, *db.DB, error) {
+	// Load configuration and ensure API key is available
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := logging.Setup(cfg.LogOptions()); err != nil {
+		return nil, nil, nil, err
+	}
+
+	apiKey, err := config.EnsureAPIKey(cfg)
+	if err != nil {
+		return cfg, nil, nil, err
+	}
+
+	// Initialize API client
+	apiClient, err := cfg.NewClient(apiKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Open database
+	database, err := openDatabase(cfg)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error opening database: %w", err)
+	}
+	apiClient.SetLedger(database)
+
+	return cfg, apiClient, database, nil
+}
+
+// openDatabase opens the history database, first moving it from its old
+// location if needed
+func openDatabase(cfg *config.Config) (*db.DB, error) {
+	oldPath, err := config.MigrateDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if oldPath != "" {
+		fmt.Fprintf(os.Stderr, "Moved the history database from %s to %s\n", oldPath, cfg.Storage.DBPath)
+	}
+	return db.Open(cfg.Storage.DBPath)
+}
+
+// exitWithSetupError reports an openBackends error and exits
+func exitWithSetupError(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if errors.Is(err, config.ErrNoAPIKey) {
+		fmt.Fprintf(os.Stderr, "\nPlease set your Reka API key:\n")
+		fmt.Fprintf(os.Stderr, "  export REKA_API_KEY=your_api_key_here\n")
+		path, _ := config.FilePath()
+		fmt.Fprintf(os.Stderr, "\nOr add it to %s:\n", path)
+		fmt.Fprintf(os.Stderr, "  {\"api_key\": \"your_api_key_here\"}\n")
+	}
+	os.Exit(1)
+}
+
 func printHelp() {
 	fmt.Println("be-my-eyes - TUI for interacting with the Reka Vision AI API")
 	fmt.Println()
 	fmt.Println("Usage:")
-	fmt.Println("  be-my-eyes [options]")
+	fmt.Println("  be-my-eyes [global options] [options]")
+	fmt.Println("  be-my-eyes [global options] <command> [options]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  ask              Ask a question about a video")
+	fmt.Println("  batch-ask        Ask the same question about several videos")
+	fmt.Println("  config           Show the configuration and where each setting comes from")
+	fmt.Println("  export           Export the clips of queries as subtitles, chapters or cuts")
+	fmt.Println("  mcp              Run a Model Context Protocol server on stdio")
+	fmt.Println("  serve            Serve a local HTTP/JSON API")
+	fmt.Println("  templates        List, save or delete prompt templates")
+	fmt.Println()
+	fmt.Println("Global options (before the command):")
+	fmt.Println("  --config <file>  Use another config file (or set BME_CONFIG)")
+	fmt.Println("  --db <file>      Use another history database")
+	fmt.Println("  --debug          Log API requests and responses, redacted, to the log file")
+	fmt.Println("  --profile <name> Use a profile of the config file (or set BME_PROFILE)")
+	fmt.Println("  --record <file>  Record the API requests and responses to a cassette file")
+	fmt.Println("  --replay <file>  Answer API requests from a recorded cassette, offline")
+	fmt.Println("  --set key=value  Override a setting for this run (repeatable)")
 	fmt.Println()
 	fmt.Println("Options:")
 	fmt.Println("  -h, --help       Show this help message")
@@ -81,7 +249,12 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("Environment:")
 	fmt.Println("  REKA_API_KEY     Your Reka API key (or use config file)")
+	fmt.Println("  BME_*            Any setting, e.g. BME_API_TIMEOUT=1m for api.timeout")
 	fmt.Println()
 	fmt.Println("Config file:")
-	fmt.Println("  ~/.config/be-my-eyes/config.json containing {\"api_key\": \"...\"}")
+	fmt.Println("  $XDG_CONFIG_HOME/be-my-eyes/config.json (~/.config/be-my-eyes/config.json)")
+	fmt.Println("  containing {\"api_key\": \"...\"}; the history is in $XDG_DATA_HOME/be-my-eyes")
+	fmt.Println()
+	fmt.Println("Settings are resolved as defaults < config file < profile < BME_* environment < --set.")
+	fmt.Println("Run 'be-my-eyes config show --effective' to see them all.")
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/fboucher/be-my-eyes/internal/mcp"
+)
+
+// runMCP implements the mcp command: a Model Context Protocol server on
+// stdin/stdout for AI assistants
+func runMCP(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: be-my-eyes mcp")
+		return 2
+	}
+
+	cfg, apiClient, database, err := openBackends()
+	if err != nil {
+		exitWithSetupError(err)
+	}
+	defer database.Close()
+	loc, _ := cfg.Location() // validated by openBackends
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := mcp.New(apiClient, database, loc, cfg.AnswerCacheTTL(), *cfg.Defaults.UploadIndex).Serve(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
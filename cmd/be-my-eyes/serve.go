@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/fboucher/be-my-eyes/internal/server"
+)
+
+// runServe implements the serve command: a local HTTP/JSON API over the
+// video library and the history
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: be-my-eyes serve [options]")
+		fmt.Fprintln(fs.Output(), "\nClients authenticate with \"Authorization: Bearer <token>\".")
+		fmt.Fprintln(fs.Output(), "\nOptions:")
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", "", "address to listen on (default: the server.addr setting, 127.0.0.1:8787)")
+	token := fs.String("token", os.Getenv("BME_SERVER_TOKEN"), "token clients must send (default: $BME_SERVER_TOKEN, or generated)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, apiClient, database, err := openBackends()
+	if err != nil {
+		exitWithSetupError(err)
+	}
+	defer database.Close()
+	loc, _ := cfg.Location() // validated by openBackends
+	if *addr == "" {
+		*addr = cfg.Server.Addr
+	}
+
+	logger := log.New(os.Stderr, "be-my-eyes: ", log.LstdFlags)
+
+	if *token == "" {
+		if *token, err = server.GenerateToken(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		logger.Printf("generated token: %s", *token)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Printf("listening on http://%s", *addr)
+	if err := server.New(apiClient, database, *token, loc, cfg.AnswerCacheTTL(), *cfg.Defaults.UploadIndex, logger).ListenAndServe(ctx, *addr); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
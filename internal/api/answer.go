@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+// ParseChatResponse extracts the global answer and the video clips from the
+// chat_response field of a QA response. The field contains an escaped JSON
+// string; when it can't be parsed the raw response is returned as the answer.
+func ParseChatResponse(chatResponse string) (string, []models.VideoClip) {
+	var chatData struct {
+		Sections []struct {
+			SectionID   string                   `json:"section_id"`
+			SectionType string                   `json:"section_type"`
+			Markdown    string                   `json:"markdown,omitempty"`
+			VideoClips  []map[string]interface{} `json:"video_clips,omitempty"`
+		} `json:"sections"`
+	}
+
+	// Default answer is the raw response in case parsing fails
+	globalAnswer := chatResponse
+	var videoClips []models.VideoClip
+
+	if err := json.Unmarshal([]byte(chatResponse), &chatData); err != nil {
+		return globalAnswer, nil
+	}
+
+	for _, section := range chatData.Sections {
+		if section.SectionType == "markdown" && section.SectionID == "1" {
+			// This is the global answer
+			globalAnswer = section.Markdown
+		} else if section.SectionType == "video-clips-info" {
+			for _, clipMap := range section.VideoClips {
+				clip := models.VideoClip{}
+
+				if clipID, ok := clipMap["video_clip_id"].(string); ok {
+					clip.ClipID = clipID
+				}
+				if startTime, ok := clipMap["video_clip_start_time"].(float64); ok {
+					clip.StartTime = startTime
+				}
+				if endTime, ok := clipMap["video_clip_end_time"].(float64); ok {
+					clip.EndTime = endTime
+				}
+				if info, ok := clipMap["video_clip_info"].(string); ok {
+					clip.Info = info
+				}
+
+				videoClips = append(videoClips, clip)
+			}
+		}
+	}
+
+	return globalAnswer, videoClips
+}
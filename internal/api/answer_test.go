@@ -0,0 +1,55 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+func TestParseChatResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		wantAnswer string
+		wantClips  []models.VideoClip
+	}{
+		{
+			name: "answer and clips",
+			response: `{"sections":[
+				{"section_id":"1","section_type":"markdown","markdown":"A cat walks by."},
+				{"section_id":"2","section_type":"markdown","markdown":"Other details."},
+				{"section_id":"3","section_type":"video-clips-info","video_clips":[
+					{"video_clip_id":"c1","video_clip_start_time":1.5,"video_clip_end_time":4,"video_clip_info":"The cat"},
+					{"video_clip_id":"c2","video_clip_start_time":"bad"}
+				]}
+			]}`,
+			wantAnswer: "A cat walks by.",
+			wantClips: []models.VideoClip{
+				{ClipID: "c1", StartTime: 1.5, EndTime: 4, Info: "The cat"},
+				{ClipID: "c2"},
+			},
+		},
+		{
+			name:       "no global answer section",
+			response:   `{"sections":[{"section_id":"2","section_type":"markdown","markdown":"Other"}]}`,
+			wantAnswer: `{"sections":[{"section_id":"2","section_type":"markdown","markdown":"Other"}]}`,
+		},
+		{
+			name:       "plain text",
+			response:   "Nobody is there.",
+			wantAnswer: "Nobody is there.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, clips := ParseChatResponse(tt.response)
+			if answer != tt.wantAnswer {
+				t.Errorf("answer = %q, want %q", answer, tt.wantAnswer)
+			}
+			if !reflect.DeepEqual(clips, tt.wantClips) {
+				t.Errorf("clips = %+v, want %+v", clips, tt.wantClips)
+			}
+		})
+	}
+}
@@ -2,64 +2,168 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/fboucher/be-my-eyes/internal/logging"
 	"github.com/fboucher/be-my-eyes/internal/models"
 )
 
 const (
-	// Base URL for the Reka Vision API
-	baseURL = "https://vision-agent.api.reka.ai"
+	// DefaultBaseURL is the base URL of the Reka Vision API
+	DefaultBaseURL = "https://vision-agent.api.reka.ai"
+
+	// DefaultTimeout is how long a request may take
+	DefaultTimeout = 30 * time.Second
 )
 
 // Client represents an API client for the Reka Vision API
 type Client struct {
 	apiKey     string
+	baseURL    string
 	httpClient *http.Client
+	limiter    *limiter
+	ledger     Ledger
+	offline    bool            // responses are replayed, not asked from the API
+	ctx        context.Context // requests stop when it is done, nil for never
 }
 
-// DoRawRequest allows custom API calls for endpoints not covered by typed methods
-func (c *Client) DoRawRequest(method, endpoint string, body interface{}) ([]byte, error) {
-	var bodyReader io.Reader
-	if body != nil {
-		jsonData, err := json.Marshal(body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request body: %w", err)
-		}
-		bodyReader = bytes.NewReader(jsonData)
-	}
+// WithContext returns a client whose requests, and waits for the rate
+// limit, stop when ctx is done. It shares the rate limits and the ledger of
+// c.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	scoped := *c
+	scoped.ctx = ctx
+	return &scoped
+}
 
-	req, err := http.NewRequest(method, baseURL+endpoint, bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// context returns the context of the requests of the client
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
 	}
+	return c.ctx
+}
 
-	req.Header.Set("X-Api-Key", c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+// SetRateLimits holds the requests of the client to the given rates. A
+// request over the rate waits for its turn instead of failing.
+func (c *Client) SetRateLimits(limits RateLimits) {
+	c.limiter.mu.Lock()
+	defer c.limiter.mu.Unlock()
+	c.limiter.limits = limits
+}
+
+// SetTransport sends the requests of the client through rt, e.g. to record
+// or replay them
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
+// SetOffline marks the responses of the client as replayed rather than
+// asked from the API, e.g. from a cassette. Offline requests neither wait for
+// the rate limits nor are recorded in the ledger.
+func (c *Client) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// SetLedger records every request of the client in ledger
+func (c *Client) SetLedger(ledger Ledger) {
+	c.ledger = ledger
+}
+
+// send performs a request, waiting for the rate limit of the endpoint, and
+// returns the body of a successful response. Every request is logged with
+// its timing, status and, at debug level, a preview of both bodies.
+func (c *Client) send(req *http.Request, endpoint string, body []byte) ([]byte, error) {
+	if err := c.beforeRequest(req.Method, endpoint); err != nil {
+		return nil, err
+	}
+	log := slog.With("method", req.Method, "endpoint", endpoint)
+	log.Debug("api request", "body", logging.Preview(body))
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		log.Warn("api request failed", "duration", time.Since(start).Round(time.Millisecond), "error", err)
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
+	duration := time.Since(start).Round(time.Millisecond)
 	if err != nil {
+		log.Warn("api response unreadable", "status", resp.StatusCode, "duration", duration, "error", err)
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		log.Warn("api request rejected", "status", resp.StatusCode, "duration", duration, "body", logging.Preview(respBody))
+		// The body may echo the request and its API key
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, logging.Redact(string(respBody)))
 	}
 
+	log.Info("api response", "status", resp.StatusCode, "duration", duration, "bytes", len(respBody))
+	log.Debug("api response body", "body", logging.Preview(respBody))
 	return respBody, nil
 }
 
+// beforeRequest waits for the rate limit of the endpoint, then records the
+// call. The ledger is informational: failing to record doesn't stop the call.
+// It fails when the context of the client is done while waiting. Offline
+// requests skip both.
+func (c *Client) beforeRequest(method, endpoint string) error {
+	if c.offline {
+		return nil
+	}
+	name := EndpointName(method, endpoint)
+	if wait := c.limiter.wait(name); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-c.context().Done():
+			return fmt.Errorf("failed to wait for the %s rate limit: %w", name, c.context().Err())
+		}
+	}
+	if c.ledger != nil {
+		c.ledger.RecordAPICall(name)
+	}
+	return nil
+}
+
+// DoRawRequest allows custom API calls for endpoints not covered by typed methods
+func (c *Client) DoRawRequest(method, endpoint string, body interface{}) ([]byte, error) {
+	var bodyReader io.Reader
+	var jsonData []byte
+	if body != nil {
+		var err error
+		jsonData, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		bodyReader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(c.context(), method, c.baseURL+endpoint, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("X-Api-Key", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.send(req, endpoint, jsonData)
+}
+
 // UploadVideo uploads a video with multipart/form-data format
 func (c *Client) UploadVideo(videoName, videoURL string, index bool) ([]byte, error) {
 	var buf bytes.Buffer
@@ -81,7 +185,8 @@ func (c *Client) UploadVideo(videoName, videoURL string, index bool) ([]byte, er
 		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", baseURL+"/videos/upload", &buf)
+	body := buf.Bytes()
+	req, err := http.NewRequestWithContext(c.context(), "POST", c.baseURL+"/videos/upload", &buf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -89,46 +194,42 @@ func (c *Client) UploadVideo(videoName, videoURL string, index bool) ([]byte, er
 	req.Header.Set("X-Api-Key", c.apiKey)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	return respBody, nil
+	return c.send(req, "/videos/upload", body)
 }
 
 // NewClient creates a new API client with the given API key
 func NewClient(apiKey string) *Client {
+	return NewClientWithOptions(apiKey, DefaultBaseURL, DefaultTimeout)
+}
+
+// NewClientWithOptions creates a new API client for another base URL or
+// request timeout
+func NewClientWithOptions(apiKey, baseURL string, timeout time.Duration) *Client {
+	logging.AddSecret(apiKey)
 	return &Client{
-		apiKey: apiKey,
+		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: timeout,
 		},
+		limiter: &limiter{buckets: make(map[string]*bucket)},
 	}
 }
 
 // doRequest performs an HTTP request with the API key header
 func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, error) {
 	var bodyReader io.Reader
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
 		bodyReader = bytes.NewReader(jsonData)
 	}
 
-	req, err := http.NewRequest(method, baseURL+endpoint, bodyReader)
+	req, err := http.NewRequestWithContext(c.context(), method, c.baseURL+endpoint, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -136,22 +237,7 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 	req.Header.Set("X-Api-Key", c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	return respBody, nil
+	return c.send(req, endpoint, jsonData)
 }
 
 // GetVideos retrieves information about one or more videos by their IDs
@@ -215,3 +301,36 @@ func (c *Client) AskQuestion(videoID, question string) (*models.QAResponse, erro
 
 	return &response, nil
 }
+
+// DeleteVideo removes a video from the library
+func (c *Client) DeleteVideo(videoID string) error {
+	if videoID == "" {
+		return fmt.Errorf("video ID is required")
+	}
+	_, err := c.doRequest("DELETE", "/videos/"+url.PathEscape(videoID), nil)
+	return err
+}
+
+// Search finds the segments of indexed videos matching a text query across
+// the library, ranked by score. maxResults of 0 uses the API default.
+func (c *Client) Search(query string, maxResults int) (*models.SearchResponse, error) {
+	req := models.SearchRequest{
+		Query:      query,
+		MaxResults: maxResults,
+	}
+
+	respBody, err := c.doRequest("POST", "/search", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response models.SearchResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	sort.SliceStable(response.Results, func(i, j int) bool {
+		return response.Results[i].Score > response.Results[j].Score
+	})
+	return &response, nil
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+func TestWithContextCancelsRequest(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := NewClientWithOptions("key", srv.URL, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.WithContext(ctx).UploadVideo("title", "https://example.com/v.mp4", true)
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request kept running after its context was cancelled")
+	}
+}
+
+func TestWithContextCancelsRateLimitWait(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"results":[]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClientWithOptions("key", srv.URL, time.Minute)
+	client.SetRateLimits(RateLimits{Default: Rate{Requests: 1, Per: time.Hour}})
+	if _, err := client.GetAllVideos(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The shared limiter makes the second call wait an hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.WithContext(ctx).GetAllVideos(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+// countingLedger counts the recorded calls per endpoint
+type countingLedger map[string]int
+
+func (l countingLedger) RecordAPICall(endpoint string) error {
+	l[endpoint]++
+	return nil
+}
+
+func TestOfflineSkipsRateLimitsAndLedger(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results":[]}`))
+	}))
+	defer srv.Close()
+
+	client := NewClientWithOptions("key", srv.URL, time.Minute)
+	client.SetRateLimits(RateLimits{Default: Rate{Requests: 1, Per: time.Hour}})
+	ledger := countingLedger{}
+	client.SetLedger(ledger)
+	if _, err := client.GetAllVideos(); err != nil {
+		t.Fatal(err)
+	}
+	if ledger["videos"] != 1 {
+		t.Fatalf("ledger = %v, want one videos call", ledger)
+	}
+
+	// Replayed calls must not wait for the hour or count as API calls
+	client.SetOffline(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		if _, err := client.WithContext(ctx).GetAllVideos(); err != nil {
+			t.Fatalf("offline call %d: %v", i, err)
+		}
+	}
+	if ledger["videos"] != 1 {
+		t.Errorf("ledger = %v, want the offline calls left out", ledger)
+	}
+}
+
+func TestSearchDecodesAndRanksResults(t *testing.T) {
+	var request models.SearchRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.Header.Get("X-Api-Key") != "key" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		io.WriteString(w, `{"results":[
+			{"video_id":"v1","start_timestamp":1.5,"end_timestamp":3,"score":0.2,"plain_text_caption":"a cat"},
+			{"video_id":"v2","start_timestamp":10,"end_timestamp":12.5,"score":0.9,"plain_text_caption":"a dog"},
+			{"video_id":"v3","start_timestamp":0,"end_timestamp":1,"score":0.5,"plain_text_caption":"a bird"}
+		]}`)
+	}))
+	defer srv.Close()
+
+	client := NewClientWithOptions("key", srv.URL, time.Minute)
+	response, err := client.Search("animals", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Query != "animals" || request.MaxResults != 3 {
+		t.Errorf("request = %+v", request)
+	}
+
+	want := []models.SearchResult{
+		{VideoID: "v2", StartTime: 10, EndTime: 12.5, Score: 0.9, Caption: "a dog"},
+		{VideoID: "v3", StartTime: 0, EndTime: 1, Score: 0.5, Caption: "a bird"},
+		{VideoID: "v1", StartTime: 1.5, EndTime: 3, Score: 0.2, Caption: "a cat"},
+	}
+	if len(response.Results) != len(want) {
+		t.Fatalf("results = %+v, want %+v", response.Results, want)
+	}
+	for i := range want {
+		if response.Results[i] != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i, response.Results[i], want[i])
+		}
+	}
+}
+
+func TestSearchRejectsInvalidResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"results":`)
+	}))
+	defer srv.Close()
+
+	client := NewClientWithOptions("key", srv.URL, time.Minute)
+	if _, err := client.Search("animals", 0); err == nil {
+		t.Error("Search() should fail on a truncated response")
+	}
+}
+
+func TestRejectedRequestErrorIsRedacted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request from "+r.Header.Get("X-Api-Key"), http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	client := NewClientWithOptions("echoed-s3cret", srv.URL, time.Minute)
+	_, err := client.GetVideos([]string{"v1"})
+	if err == nil {
+		t.Fatal("GetVideos() should fail")
+	}
+	if strings.Contains(err.Error(), "echoed-s3cret") || !strings.Contains(err.Error(), "bad request from [REDACTED]") {
+		t.Errorf("error = %q, want the body with the API key redacted", err)
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoints are the names of the API endpoints, as used for rate limits and
+// in the call ledger
+var Endpoints = []string{"chat", "videos", "upload", "search", "delete"}
+
+// EndpointName returns the name of the endpoint a request goes to
+func EndpointName(method, endpoint string) string {
+	switch {
+	case endpoint == "/qa/chat":
+		return "chat"
+	case endpoint == "/videos/get":
+		return "videos"
+	case endpoint == "/videos/upload":
+		return "upload"
+	case endpoint == "/search":
+		return "search"
+	case method == "DELETE" && strings.HasPrefix(endpoint, "/videos/"):
+		return "delete"
+	default:
+		return strings.TrimPrefix(endpoint, "/")
+	}
+}
+
+// Rate is a number of requests allowed per period. The zero Rate allows
+// any number of requests.
+type Rate struct {
+	Requests int
+	Per      time.Duration
+}
+
+// rateUnits are the period shorthands of ParseRate
+var rateUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+}
+
+// ParseRate reads a rate such as "10/m" (per second, minute, hour or day)
+// or "5/30s". An empty string or "0" is no limit.
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Rate{}, nil
+	}
+
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("rate must look like \"10/m\", got %q", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 1 {
+		return Rate{}, fmt.Errorf("rate must allow at least 1 request, got %q", s)
+	}
+
+	period = strings.TrimSpace(period)
+	per, ok := rateUnits[period]
+	if !ok {
+		per, err = time.ParseDuration(period)
+		if err != nil || per <= 0 {
+			return Rate{}, fmt.Errorf("rate period must be s, m, h, d or a duration such as 30s, got %q", period)
+		}
+	}
+	return Rate{Requests: n, Per: per}, nil
+}
+
+// String writes the rate the way ParseRate reads it
+func (r Rate) String() string {
+	if r.Requests == 0 {
+		return ""
+	}
+	for unit, per := range rateUnits {
+		if r.Per == per {
+			return fmt.Sprintf("%d/%s", r.Requests, unit)
+		}
+	}
+	return fmt.Sprintf("%d/%s", r.Requests, r.Per)
+}
+
+// RateLimits are the rates requests are held to: Default for every
+// endpoint, unless Endpoints has a rate for it
+type RateLimits struct {
+	Default   Rate
+	Endpoints map[string]Rate
+}
+
+// rate returns the rate of an endpoint
+func (l RateLimits) rate(endpoint string) Rate {
+	if r, ok := l.Endpoints[endpoint]; ok && r.Requests > 0 {
+		return r
+	}
+	return l.Default
+}
+
+// Ledger records the calls made to the API, e.g. to count them per day
+type Ledger interface {
+	RecordAPICall(endpoint string) error
+}
+
+// bucket is a token bucket holding up to Requests tokens, refilled at
+// Requests per Per. Tokens go negative while requests are queued.
+type bucket struct {
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (b *bucket) reserve(now time.Time) time.Duration {
+	perSecond := float64(b.rate.Requests) / b.rate.Per.Seconds()
+	b.tokens += now.Sub(b.last).Seconds() * perSecond
+	if b.tokens > float64(b.rate.Requests) {
+		b.tokens = float64(b.rate.Requests)
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / perSecond * float64(time.Second))
+}
+
+// limiter holds one token bucket per endpoint
+type limiter struct {
+	mu      sync.Mutex
+	limits  RateLimits
+	buckets map[string]*bucket
+}
+
+// wait returns how long a request to an endpoint must wait for its turn
+func (l *limiter) wait(endpoint string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := l.limits.rate(endpoint)
+	if rate.Requests == 0 {
+		return 0
+	}
+	b, ok := l.buckets[endpoint]
+	if !ok || b.rate != rate {
+		b = &bucket{rate: rate, tokens: float64(rate.Requests), last: time.Now()}
+		l.buckets[endpoint] = b
+	}
+	return b.reserve(time.Now())
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Rate
+		wantErr bool
+	}{
+		{"", Rate{}, false},
+		{"0", Rate{}, false},
+		{"10/m", Rate{10, time.Minute}, false},
+		{" 2 / h ", Rate{2, time.Hour}, false},
+		{"1/d", Rate{1, 24 * time.Hour}, false},
+		{"5/30s", Rate{5, 30 * time.Second}, false},
+		{"10", Rate{}, true},
+		{"0/m", Rate{}, true},
+		{"x/m", Rate{}, true},
+		{"10/week", Rate{}, true},
+		{"10/-1s", Rate{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseRate(tt.text)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseRate() = %+v, %v, want %+v, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateString(t *testing.T) {
+	for _, text := range []string{"", "10/m", "1/d", "5/30s"} {
+		r, err := ParseRate(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.String(); got != text {
+			t.Errorf("ParseRate(%q).String() = %q", text, got)
+		}
+	}
+}
+
+func TestEndpointName(t *testing.T) {
+	tests := []struct {
+		method, endpoint, want string
+	}{
+		{"POST", "/qa/chat", "chat"},
+		{"GET", "/videos/get", "videos"},
+		{"POST", "/videos/upload", "upload"},
+		{"POST", "/search", "search"},
+		{"DELETE", "/videos/abc", "delete"},
+		{"GET", "/other", "other"},
+	}
+	for _, tt := range tests {
+		if got := EndpointName(tt.method, tt.endpoint); got != tt.want {
+			t.Errorf("EndpointName(%s %s) = %s, want %s", tt.method, tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitsPerEndpoint(t *testing.T) {
+	limits := RateLimits{
+		Default:   Rate{10, time.Minute},
+		Endpoints: map[string]Rate{"chat": {2, time.Second}, "search": {}},
+	}
+	if got := limits.rate("chat"); got != (Rate{2, time.Second}) {
+		t.Errorf("chat rate = %+v", got)
+	}
+	if got := limits.rate("search"); got != limits.Default {
+		t.Errorf("search rate = %+v, want the default", got)
+	}
+	if got := limits.rate("videos"); got != limits.Default {
+		t.Errorf("videos rate = %+v, want the default", got)
+	}
+}
+
+func TestBucketReserve(t *testing.T) {
+	start := time.Now()
+	b := &bucket{rate: Rate{2, time.Second}, tokens: 2, last: start}
+
+	// the burst goes through, then requests queue half a second apart
+	waits := []time.Duration{0, 0, 500 * time.Millisecond, time.Second}
+	for i, want := range waits {
+		if got := b.reserve(start); got != want {
+			t.Errorf("reserve %d = %s, want %s", i, got, want)
+		}
+	}
+
+	// tokens refill, but never past the burst
+	b = &bucket{rate: Rate{2, time.Second}, tokens: 0, last: start}
+	if got := b.reserve(start.Add(time.Hour)); got != 0 || b.tokens != 1 {
+		t.Errorf("after an hour: wait %s with %v tokens left, want 0 with 1", got, b.tokens)
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := &limiter{
+		limits:  RateLimits{Endpoints: map[string]Rate{"chat": {1, time.Hour}}},
+		buckets: make(map[string]*bucket),
+	}
+	if got := l.wait("chat"); got != 0 {
+		t.Errorf("first chat wait = %s, want 0", got)
+	}
+	if got := l.wait("chat"); got < 59*time.Minute {
+		t.Errorf("second chat wait = %s, want about an hour", got)
+	}
+	if got := l.wait("videos"); got != 0 {
+		t.Errorf("unlimited videos wait = %s, want 0", got)
+	}
+}
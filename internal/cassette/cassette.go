@@ -0,0 +1,300 @@
+// Package cassette records the HTTP exchanges of the API client to a file
+// and replays them, so the TUI and commands can run without network access
+// and tests get the same answers every time. API keys are never written.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Modes of a cassette transport
+const (
+	Record = "record"
+	Replay = "replay"
+)
+
+// apiKeyHeader is the header holding the API key
+const apiKeyHeader = "X-Api-Key"
+
+// redacted replaces the API key in recorded requests and responses
+const redacted = "[REDACTED]"
+
+// Request is a recorded request. Body is normalized so requests can be
+// matched: compact JSON, or the fields of a form.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Interaction is a request and the response it got
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the file format: the interactions in the order they happened
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// transports holds one transport per cassette file, so every client of the
+// process records to, or replays from, the same cassette
+var (
+	transportsMu sync.Mutex
+	transports   = make(map[string]http.RoundTripper)
+)
+
+// Open returns the transport of a cassette file for a mode. Recording
+// starts a new cassette; replaying reads it, failing if it doesn't exist.
+// Every call with the same path returns the same transport.
+func Open(mode, path string) (http.RoundTripper, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cassette path %q: %w", path, err)
+	}
+	key := mode + "\x00" + abs
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if rt, ok := transports[key]; ok {
+		return rt, nil
+	}
+
+	var rt http.RoundTripper
+	switch mode {
+	case Record:
+		rt = &recorder{path: abs, next: http.DefaultTransport}
+	case Replay:
+		player, err := newPlayer(abs)
+		if err != nil {
+			return nil, err
+		}
+		rt = player
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q, use %s or %s", mode, Record, Replay)
+	}
+	transports[key] = rt
+	return rt, nil
+}
+
+// recorder sends requests on and writes every exchange to the cassette
+type recorder struct {
+	path string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// RoundTrip sends the request and records it with its response
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// An error body may echo the key
+	key := req.Header.Get(apiKeyHeader)
+	interaction := Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       redact(string(body), key),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// save writes the cassette, creating its directory if needed
+func (r *recorder) save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// player answers requests from a cassette without any network access
+type player struct {
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// newPlayer reads a cassette to replay
+func newPlayer(path string) (*player, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &player{path: path, cassette: c, used: make([]bool, len(c.Interactions))}, nil
+}
+
+// RoundTrip returns the recorded response of the first matching request
+// not replayed yet. Once all are replayed, the last one is served again, so
+// a refresh can be repeated. Requests that were never recorded fail.
+func (p *player) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	last := -1
+	for i, interaction := range p.cassette.Interactions {
+		if !interaction.Request.matches(recorded) {
+			continue
+		}
+		last = i
+		if !p.used[i] {
+			p.used[i] = true
+			return interaction.Response.httpResponse(req), nil
+		}
+	}
+	if last >= 0 {
+		return p.cassette.Interactions[last].Response.httpResponse(req), nil
+	}
+	return nil, fmt.Errorf("cassette %s has no response for %s %s %s", filepath.Base(p.path), recorded.Method, recorded.URL, recorded.Body)
+}
+
+// matches reports whether two requests are the same call. The host is left
+// out so a cassette can be replayed with another base URL.
+func (r Request) matches(other Request) bool {
+	return r.Method == other.Method && target(r.URL) == target(other.URL) && r.Body == other.Body
+}
+
+// target returns the path and query of a URL
+func target(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.RequestURI()
+}
+
+// httpResponse builds the response to a replayed request
+func (r Response) httpResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// recordRequest copies a request for the cassette, with the API key
+// redacted. The request body is read and put back.
+func recordRequest(req *http.Request) (Request, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return Request{}, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	header := req.Header.Clone()
+	if header.Get(apiKeyHeader) != "" {
+		header.Set(apiKeyHeader, redacted)
+	}
+	return Request{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: header,
+		Body:   normalizeBody(req.Header.Get("Content-Type"), body),
+	}, nil
+}
+
+// normalizeBody returns a body that is the same for the same call: JSON is
+// compacted and multipart forms, whose boundary is random, become their
+// encoded fields
+func normalizeBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json":
+		var b bytes.Buffer
+		if json.Compact(&b, body) == nil {
+			return b.String()
+		}
+	case strings.HasPrefix(mediaType, "multipart/"):
+		form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(int64(len(body)))
+		if err == nil {
+			defer form.RemoveAll()
+			return url.Values(form.Value).Encode()
+		}
+	}
+	return string(body)
+}
+
+// redact replaces the API key in s
+func redact(s, key string) string {
+	if key == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, key, redacted)
+}
@@ -0,0 +1,157 @@
+package cassette
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKey = "secret-key-42"
+
+// send makes a request through rt and returns the status and body
+func send(t *testing.T, rt http.RoundTripper, method, url, contentType, body string) (int, string, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(apiKeyHeader, testKey)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data), nil
+}
+
+// form returns a multipart form with a random boundary
+func form(t *testing.T, name string) (string, string) {
+	t.Helper()
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	w.WriteField("video_name", name)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return w.FormDataContentType(), b.String()
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get(apiKeyHeader) != testKey {
+			http.Error(w, "no key", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/videos/get":
+			io.WriteString(w, `{"results":[{"video_id":"v1"}]}`)
+		case "/videos/upload":
+			r.ParseMultipartForm(1 << 20)
+			io.WriteString(w, `{"video_id":"`+r.FormValue("video_name")+`"}`)
+		default:
+			http.Error(w, "unknown key "+testKey, http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	path := filepath.Join(t.TempDir(), "api.json")
+	recorder, err := Open(Record, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadType, upload := form(t, "door")
+	for _, r := range []struct{ method, path, contentType, body string }{
+		{"POST", "/videos/get", "application/json", `{"video_ids": []}`},
+		{"POST", "/videos/upload", uploadType, upload},
+		{"GET", "/nope", "", ""},
+	} {
+		if _, _, err := send(t, recorder, r.method, api.URL+r.path, r.contentType, r.body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), testKey) {
+		t.Fatalf("the cassette holds the API key:\n%s", data)
+	}
+
+	// Replaying needs neither the API nor the same host, JSON spacing or
+	// form boundary
+	player, err := Open(Replay, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadType, upload = form(t, "door")
+	tests := []struct {
+		method, path, contentType, body string
+		status                          int
+		want                            string
+	}{
+		{"POST", "/videos/get", "application/json", `{"video_ids":[]}`, 200, `{"results":[{"video_id":"v1"}]}`},
+		{"POST", "/videos/upload", uploadType, upload, 200, `{"video_id":"door"}`},
+		{"GET", "/nope", "", "", 404, "unknown key [REDACTED]\n"},
+		{"POST", "/videos/get", "application/json", `{"video_ids":[]}`, 200, `{"results":[{"video_id":"v1"}]}`},
+	}
+	for _, tt := range tests {
+		status, body, err := send(t, player, tt.method, "http://replay.invalid"+tt.path, tt.contentType, tt.body)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.path, err)
+		}
+		if status != tt.status || body != tt.want {
+			t.Errorf("%s %s = %d %q, want %d %q", tt.method, tt.path, status, body, tt.status, tt.want)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("the API got %d calls, want only the 3 recorded ones", calls)
+	}
+}
+
+func TestReplayFailsOnUnmatchedRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.json")
+	cassette := `{"interactions":[{"request":{"method":"POST","url":"https://api/videos/get","body":"{\"video_ids\":[]}"},"response":{"status_code":200,"body":"{}"}}]}`
+	if err := os.WriteFile(path, []byte(cassette), 0644); err != nil {
+		t.Fatal(err)
+	}
+	player, err := Open(Replay, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{"POST", "/videos/get", `{"video_ids":["v1"]}`},
+		{"GET", "/videos/get", `{"video_ids":[]}`},
+		{"POST", "/search", `{"video_ids":[]}`},
+	}
+	for _, tt := range tests {
+		if _, _, err := send(t, player, tt.method, "https://api"+tt.path, "application/json", tt.body); err == nil {
+			t.Errorf("%s %s %s should fail", tt.method, tt.path, tt.body)
+		}
+	}
+
+	if _, err := Open(Replay, filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("replaying a missing cassette should fail")
+	}
+	if _, err := Open("rewind", path); err == nil {
+		t.Error("an unknown mode should fail")
+	}
+}
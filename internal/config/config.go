@@ -1,34 +1,279 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/cassette"
+	"github.com/fboucher/be-my-eyes/internal/logging"
 )
 
-// Config represents the application configuration
+// ErrNoAPIKey is returned when neither the config file nor the environment
+// provides an API key
+var ErrNoAPIKey = errors.New("no API key found. Please set REKA_API_KEY environment variable or add it to the config file")
+
+// DefaultMaxConcurrentJobs is the number of background jobs (questions,
+// uploads) run at the same time when the config doesn't say otherwise
+const DefaultMaxConcurrentJobs = 2
+
+// Config represents the application configuration. Sections are pointers so
+// a config file only holds what it sets; the effective configuration from
+// Resolve always has every section.
 type Config struct {
-	APIKey string `json:"api_key"`
+	APIKey               string   `json:"api_key"`
+	APIKeyCommand        string   `json:"api_key_command,omitempty"` // prints the API key, e.g. "pass show reka"
+	APIKeyCommandTimeout Duration `json:"api_key_command_timeout,omitempty"`
+	SaveEnvAPIKey        *bool    `json:"save_env_api_key,omitempty"` // save a key from REKA_API_KEY to the config file
+	MaxConcurrentJobs    int      `json:"max_concurrent_jobs,omitempty"`
+	TimeZone             string   `json:"time_zone,omitempty"` // IANA name for wall-clock times, local time when empty
+	Profile              string   `json:"profile,omitempty"`   // profile used when none is chosen
+
+	API      *APIConfig      `json:"api,omitempty"`
+	Storage  *StorageConfig  `json:"storage,omitempty"`
+	UI       *UIConfig       `json:"ui,omitempty"`
+	Defaults *DefaultsConfig `json:"defaults,omitempty"`
+	Server   *ServerConfig   `json:"server,omitempty"`
+	Cache    *CacheConfig    `json:"cache,omitempty"`
+	Log      *LogConfig      `json:"log,omitempty"`
+
+	Profiles map[string]*ProfileConfig `json:"profiles,omitempty"`
+
+	overrides map[string]string // --set values given to Resolve, kept to switch profiles
+	sources   map[string]string // layer that set each setting, from Resolve
+	warnings  []string          // problems worth telling the user, from Resolve
 }
 
-// configDir returns the configuration directory path
-// This is ~/.config/be-my-eyes
-func configDir() (string, error) {
-	homeDir, err := os.UserHomeDir()
+// ProfileConfig holds the settings of a named profile: a separate account
+// with its own key, endpoint and history. Unset fields of a profile don't
+// fall back to the top-level key; the history database defaults to one per
+// profile.
+type ProfileConfig struct {
+	APIKey        string         `json:"api_key,omitempty"`
+	APIKeyCommand string         `json:"api_key_command,omitempty"`
+	API           *APIConfig     `json:"api,omitempty"`
+	Storage       *StorageConfig `json:"storage,omitempty"`
+}
+
+// APIConfig holds the Reka API settings
+type APIConfig struct {
+	BaseURL      string            `json:"base_url,omitempty"`
+	Timeout      Duration          `json:"timeout,omitempty"`
+	RateLimit    string            `json:"rate_limit,omitempty"`    // e.g. "10/m" for every endpoint, empty for none
+	RateLimits   map[string]string `json:"rate_limits,omitempty"`   // endpoint -> rate, over rate_limit
+	DailyQuota   int               `json:"daily_quota,omitempty"`   // requests allowed per day, 0 when unknown
+	QuotaWarning int               `json:"quota_warning,omitempty"` // percent of the quota to warn at
+	Cassette     string            `json:"cassette,omitempty"`      // file to record requests to or replay them from, in the cache dir by default
+	CassetteMode string            `json:"cassette_mode,omitempty"` // record or replay, empty for the network
+}
+
+// StorageConfig holds where data is kept
+type StorageConfig struct {
+	DBPath   string `json:"db_path,omitempty"`
+	CacheDir string `json:"cache_dir,omitempty"` // data that can be rebuilt
+}
+
+// UIConfig holds the TUI settings
+type UIConfig struct {
+	LayoutSplit float64                 `json:"layout_split,omitempty"` // share of the width used by the left column
+	Theme       *ThemeConfig            `json:"theme,omitempty"`
+	Themes      map[string]*ThemeConfig `json:"themes,omitempty"`      // user-defined themes, by name
+	Keybindings map[string]string       `json:"keybindings,omitempty"` // action -> key
+}
+
+// ThemeConfig holds the TUI colors: ANSI 256 numbers or #RRGGBB. The colors
+// that are set replace those of the named theme.
+type ThemeConfig struct {
+	Name   string `json:"name,omitempty"`   // theme the colors start from, built-in or from ui.themes
+	Accent string `json:"accent,omitempty"` // active box, status, dialogs
+	Border string `json:"border,omitempty"` // inactive boxes
+	Title  string `json:"title,omitempty"`
+	Muted  string `json:"muted,omitempty"` // footer and hints
+	Focus  string `json:"focus,omitempty"` // selected field or entry
+}
+
+// LogConfig holds the settings of the debug log
+type LogConfig struct {
+	Level    string `json:"level,omitempty"`     // debug, info, warn, error or off
+	File     string `json:"file,omitempty"`      // log file, rotated next to itself
+	MaxSize  int    `json:"max_size,omitempty"`  // megabytes written before the file is rotated
+	MaxFiles int    `json:"max_files,omitempty"` // rotated files kept
+}
+
+// DefaultsConfig holds the default values of actions
+type DefaultsConfig struct {
+	UploadIndex *bool `json:"upload_index,omitempty"` // index uploaded videos for questions
+}
+
+// ServerConfig holds the serve command settings
+type ServerConfig struct {
+	Addr string `json:"addr,omitempty"`
+}
+
+// CacheConfig holds the answer cache settings
+type CacheConfig struct {
+	Enabled *bool    `json:"enabled,omitempty"` // reuse the answer to a question already asked about a video
+	TTL     Duration `json:"ttl,omitempty"`     // how long an answer is reused
+}
+
+// Duration is a time.Duration written as "30s" in the config file
+type Duration time.Duration
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reads a duration string such as "30s" or "1m30s". null
+// leaves the duration as it is.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\"")
+	}
+	parsed, err := time.ParseDuration(s)
 	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// JobLimit returns the configured number of concurrent background jobs,
+// falling back to DefaultMaxConcurrentJobs
+func (c *Config) JobLimit() int {
+	if c == nil || c.MaxConcurrentJobs <= 0 {
+		return DefaultMaxConcurrentJobs
+	}
+	return c.MaxConcurrentJobs
+}
+
+// RateLimits returns the rate limits of the API client. Rates are checked
+// by Validate; invalid ones are no limit.
+func (c *Config) RateLimits() api.RateLimits {
+	limits := api.RateLimits{Endpoints: make(map[string]api.Rate)}
+	if c == nil || c.API == nil {
+		return limits
+	}
+	limits.Default, _ = api.ParseRate(c.API.RateLimit)
+	for endpoint, s := range c.API.RateLimits {
+		if rate, err := api.ParseRate(s); err == nil {
+			limits.Endpoints[endpoint] = rate
+		}
+	}
+	return limits
+}
+
+// NewClient creates an API client with the API settings: base URL, timeout,
+// rate limits and the cassette to record to or replay from
+func (c *Config) NewClient(apiKey string) (*api.Client, error) {
+	client := api.NewClientWithOptions(apiKey, c.API.BaseURL, time.Duration(c.API.Timeout))
+	client.SetRateLimits(c.RateLimits())
+	if c.API.CassetteMode != "" {
+		rt, err := cassette.Open(c.API.CassetteMode, c.CassettePath())
+		if err != nil {
+			return nil, err
+		}
+		client.SetTransport(rt)
+		client.SetOffline(c.API.CassetteMode == cassette.Replay)
+	}
+	return client, nil
+}
+
+// CassettePath returns the cassette file to record to or replay from,
+// cassette.json in the cache directory when api.cassette is not set
+func (c *Config) CassettePath() string {
+	if strings.TrimSpace(c.API.Cassette) != "" {
+		return c.API.Cassette
+	}
+	return filepath.Join(c.Storage.CacheDir, "cassette.json")
+}
+
+// LogOptions returns the settings of the log
+func (c *Config) LogOptions() logging.Options {
+	return logging.Options{
+		Level:    c.Log.Level,
+		Path:     c.Log.File,
+		MaxSize:  int64(c.Log.MaxSize) << 20,
+		MaxFiles: c.Log.MaxFiles,
+	}
+}
+
+// AnswerCacheTTL returns how long answers are reused, 0 when the answer
+// cache is disabled
+func (c *Config) AnswerCacheTTL() time.Duration {
+	if c == nil || c.Cache == nil || c.Cache.Enabled == nil || !*c.Cache.Enabled {
+		return 0
 	}
-	return filepath.Join(homeDir, ".config", "be-my-eyes"), nil
+	return time.Duration(c.Cache.TTL)
 }
 
-// configFilePath returns the full path to the config file
-func configFilePath() (string, error) {
-	dir, err := configDir()
+// Location returns the time zone wall-clock times are shown in
+func (c *Config) Location() (*time.Location, error) {
+	if c == nil || c.TimeZone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.TimeZone)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("invalid time_zone %q: %w", c.TimeZone, err)
+	}
+	return loc, nil
+}
+
+// decode reads a config file over cfg, rejecting unknown settings so typos
+// don't go unnoticed
+func decode(data []byte, cfg *Config) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(cfg)
+}
+
+// fillDefaults puts back the sections and optional values of Default that a
+// config file cleared with null, so the effective configuration has them all
+func (c *Config) fillDefaults() {
+	d := Default()
+	if c.SaveEnvAPIKey == nil {
+		c.SaveEnvAPIKey = d.SaveEnvAPIKey
+	}
+	if c.API == nil {
+		c.API = d.API
+	}
+	if c.Storage == nil {
+		c.Storage = d.Storage
+	}
+	if c.UI == nil {
+		c.UI = d.UI
+	}
+	if c.UI.Theme == nil {
+		c.UI.Theme = d.UI.Theme
+	}
+	if c.Defaults == nil {
+		c.Defaults = d.Defaults
+	}
+	if c.Defaults.UploadIndex == nil {
+		c.Defaults.UploadIndex = d.Defaults.UploadIndex
+	}
+	if c.Server == nil {
+		c.Server = d.Server
+	}
+	if c.Cache == nil {
+		c.Cache = d.Cache
+	}
+	if c.Cache.Enabled == nil {
+		c.Cache.Enabled = d.Cache.Enabled
+	}
+	if c.Log == nil {
+		c.Log = d.Log
 	}
-	return filepath.Join(dir, "config.json"), nil
 }
 
 // Load reads the configuration from the config file
@@ -50,8 +295,8 @@ func Load() (*Config, error) {
 	}
 
 	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if err := decode(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
 	}
 
 	return &cfg, nil
@@ -59,21 +304,16 @@ func Load() (*Config, error) {
 
 // Save writes the configuration to the config file
 func (c *Config) Save() error {
-	dir, err := configDir()
+	path, err := configFilePath()
 	if err != nil {
 		return err
 	}
 
 	// Create config directory if it doesn't exist
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	path, err := configFilePath()
-	if err != nil {
-		return err
-	}
-
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
@@ -82,29 +322,60 @@ func (c *Config) Save() error {
 	if err := os.WriteFile(path, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
+	// WriteFile keeps the mode of an existing file; the key must stay private
+	if err := os.Chmod(path, 0600); err != nil {
+		return fmt.Errorf("failed to restrict config file permissions: %w", err)
+	}
 
 	return nil
 }
 
-// EnsureAPIKey checks if an API key is configured and prompts for one if not
-func EnsureAPIKey() (string, error) {
-	cfg, err := Load()
-	if err != nil {
-		return "", err
+// EnsureAPIKey returns the API key of the effective configuration. When
+// api_key_command is set, it is run to get the key, unless the key comes
+// from the environment or a flag. A key only given by REKA_API_KEY is saved
+// as the top-level key of the config file so it is remembered, unless
+// save_env_api_key is false; it is never saved into a named profile.
+func EnsureAPIKey(cfg *Config) (string, error) {
+	source := cfg.Source("api_key")
+	if cfg.APIKeyCommand != "" && !strings.HasPrefix(source, "env ") && source != SourceFlag {
+		key, err := runKeyCommand(cfg.APIKeyCommand, time.Duration(cfg.APIKeyCommandTimeout))
+		if err != nil {
+			return "", err
+		}
+		cfg.APIKey = key
+		if cfg.sources == nil {
+			cfg.sources = make(map[string]string)
+		}
+		cfg.sources["api_key"] = SourceCommand
+		return key, nil
 	}
 
-	if cfg.APIKey != "" {
-		return cfg.APIKey, nil
+	if cfg.APIKey == "" {
+		// Replayed responses don't need a key
+		if cfg.API.CassetteMode == cassette.Replay {
+			return "", nil
+		}
+		return "", ErrNoAPIKey
 	}
 
-	// If no API key, check environment variable
-	if apiKey := os.Getenv("REKA_API_KEY"); apiKey != "" {
-		cfg.APIKey = apiKey
-		if err := cfg.Save(); err != nil {
-			return "", fmt.Errorf("failed to save API key from environment: %w", err)
+	if source == "env "+legacyAPIKeyEnv && *cfg.SaveEnvAPIKey && cfg.Profile == "" {
+		file, err := Load()
+		if err != nil {
+			return "", err
+		}
+		if file.APIKey == "" {
+			file.SetAPIKey(DefaultProfile, cfg.APIKey)
+			if err := file.Save(); err != nil {
+				return "", fmt.Errorf("failed to save API key from environment: %w", err)
+			}
 		}
-		return apiKey, nil
 	}
 
-	return "", fmt.Errorf("no API key found. Please set REKA_API_KEY environment variable or add it to the config file")
+	return cfg.APIKey, nil
+}
+
+// Warnings returns the problems found while resolving the configuration
+// that don't prevent running, such as a config file others can read
+func (c *Config) Warnings() []string {
+	return c.warnings
 }
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateKeybindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		bindings map[string]string
+		want     string // part of the error, empty for none
+	}{
+		{"defaults", nil, ""},
+		{"rebound", map[string]string{"ask": "ctrl+a", "mark": "space"}, ""},
+		{"same key in another scope", map[string]string{"question_submit": "ctrl+p"}, ""},
+		{"conflict", map[string]string{"ask": "q"}, `key "q" is already bound to`},
+		{"reserved in the main view", map[string]string{"refresh": "enter"}, `ui.keybindings.refresh: key "enter" is reserved for select`},
+		{"reserved navigation", map[string]string{"search": "j"}, `key "j" is reserved for navigation`},
+		{"reserved in the dialog", map[string]string{"question_submit": "tab"}, `key "tab" is reserved for indent`},
+		{"typed into the dialog", map[string]string{"question_template": "t"}, "would be typed into the dialog"},
+		{"empty", map[string]string{"help": ""}, "ui.keybindings.help: must not be empty"},
+		{"unknown action", map[string]string{"fly": "f"}, "ui.keybindings.fly: unknown action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			for action, key := range tt.bindings {
+				cfg.UI.Keybindings[action] = key
+			}
+			err := cfg.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want an error with %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// appName names the application directory in each XDG base directory
+const appName = "be-my-eyes"
+
+// fileOverride replaces the config file path, see SetFilePath
+var fileOverride string
+
+// SetFilePath makes Load, Save and Resolve use the config file at path
+// (the --config flag) instead of the default one
+func SetFilePath(path string) {
+	fileOverride = path
+}
+
+// xdgDir returns the application directory in an XDG base directory: $env,
+// or ~/fallback when the variable is unset or not absolute, as the spec asks
+func xdgDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, fallback, appName), nil
+}
+
+// configDir returns the configuration directory path
+// This is $XDG_CONFIG_HOME/be-my-eyes, ~/.config/be-my-eyes by default
+func configDir() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// DataDir returns the directory of the history database
+// This is $XDG_DATA_HOME/be-my-eyes, ~/.local/share/be-my-eyes by default
+func DataDir() (string, error) {
+	return xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// CacheDir returns the directory of data that can be thrown away
+// This is $XDG_CACHE_HOME/be-my-eyes, ~/.cache/be-my-eyes by default
+func CacheDir() (string, error) {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// StateDir returns the directory of the log
+// This is $XDG_STATE_HOME/be-my-eyes, ~/.local/state/be-my-eyes by default
+func StateDir() (string, error) {
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// FilePath returns the full path to the config file
+func FilePath() (string, error) {
+	return configFilePath()
+}
+
+// configFilePath returns the full path to the config file: the --config
+// flag, then $BME_CONFIG, then config.json in the configuration directory
+func configFilePath() (string, error) {
+	if fileOverride != "" {
+		return fileOverride, nil
+	}
+	if path := os.Getenv("BME_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// legacyDBPath returns where the history database was kept before it moved
+// to the data directory
+func legacyDBPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", appName, "history.db"), nil
+}
+
+// MigrateDB moves a history database left in the old location,
+// ~/.config/be-my-eyes, to the configured database path. It only runs when
+// the path is the default one and nothing is there yet, so it happens once.
+// It returns the old path when a database was moved.
+func MigrateDB(cfg *Config) (string, error) {
+	if cfg.Source("storage.db_path") != SourceDefault {
+		return "", nil
+	}
+
+	oldPath, err := legacyDBPath()
+	if err != nil {
+		return "", err
+	}
+	newPath := cfg.Storage.DBPath
+	if oldPath == newPath {
+		return "", nil
+	}
+	if _, err := os.Stat(oldPath); err != nil {
+		return "", nil
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return "", nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	// SQLite keeps pending changes next to the database in these files
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		if _, err := os.Stat(oldPath + suffix); err != nil {
+			continue
+		}
+		if err := moveFile(oldPath+suffix, newPath+suffix); err != nil {
+			return "", fmt.Errorf("failed to move history database to %s: %w", newPath, err)
+		}
+	}
+
+	return oldPath, nil
+}
+
+// moveFile renames a file, copying it when the destination is on another
+// file system
+func moveFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(to)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(to)
+		return err
+	}
+
+	return os.Remove(from)
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile names the top-level account settings of the config file,
+// used when no profile is selected
+const DefaultProfile = "default"
+
+// profileName returns the profile to select for a name given by the user,
+// empty for the default profile
+func profileName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == DefaultProfile {
+		return ""
+	}
+	return name
+}
+
+// applyProfile replaces the account settings with those of a named profile.
+// A profile has its own key source, so the top-level key is never used for
+// it, and its own history database unless it names one.
+func (c *Config) applyProfile(name string) {
+	p := c.Profiles[name]
+	if p == nil {
+		return // reported by Validate
+	}
+
+	c.APIKey = p.APIKey
+	c.APIKeyCommand = p.APIKeyCommand
+	if p.API != nil {
+		if p.API.BaseURL != "" {
+			c.API.BaseURL = p.API.BaseURL
+		}
+		if p.API.Timeout != 0 {
+			c.API.Timeout = p.API.Timeout
+		}
+	}
+	if p.Storage != nil && p.Storage.DBPath != "" {
+		c.Storage.DBPath = p.Storage.DBPath
+	} else if dir, err := DataDir(); err == nil {
+		c.Storage.DBPath = filepath.Join(dir, "profiles", name, "history.db")
+	}
+}
+
+// ActiveProfile returns the name of the selected profile, DefaultProfile
+// when none is
+func (c *Config) ActiveProfile() string {
+	if c == nil || c.Profile == "" {
+		return DefaultProfile
+	}
+	return c.Profile
+}
+
+// ProfileNames returns the names of the profiles of the config file, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithProfile resolves the configuration again with another profile
+// selected, keeping the --set values the configuration was resolved with
+func (c *Config) WithProfile(name string) (*Config, error) {
+	overrides := make(map[string]string, len(c.overrides)+1)
+	for key, value := range c.overrides {
+		overrides[key] = value
+	}
+	overrides["profile"] = name
+	return Resolve(overrides)
+}
+
+// SetAPIKey sets the API key of a profile in a config file, the top-level
+// key for the default profile
+func (c *Config) SetAPIKey(profile, key string) {
+	profile = profileName(profile)
+	if profile == "" {
+		c.APIKey = key
+		return
+	}
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]*ProfileConfig)
+	}
+	if c.Profiles[profile] == nil {
+		c.Profiles[profile] = &ProfileConfig{}
+	}
+	c.Profiles[profile].APIKey = key
+}
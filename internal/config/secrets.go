@@ -0,0 +1,72 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// SourceCommand is the source of an API key printed by api_key_command
+const SourceCommand = "api_key_command"
+
+// DefaultAPIKeyCommandTimeout is how long api_key_command may run, e.g.
+// while a password manager asks to unlock
+const DefaultAPIKeyCommandTimeout = 30 * time.Second
+
+// runKeyCommand runs api_key_command with the shell and returns the first
+// line it prints. Its output is never included in errors.
+func runKeyCommand(command string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Stdin = os.Stdin // password managers may prompt
+	// Children of the shell may keep the output open after it is killed
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("api_key_command timed out after %s", timeout)
+	}
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return "", fmt.Errorf("api_key_command failed: %w", err)
+		}
+		return "", fmt.Errorf("api_key_command failed: %w: %s", err, message)
+	}
+
+	key, _, _ := strings.Cut(stdout.String(), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", fmt.Errorf("api_key_command printed no key")
+	}
+	return key, nil
+}
+
+// permissionWarning returns a warning when the config file can be read by
+// other users, empty otherwise. File modes don't apply on Windows.
+func permissionWarning(path string) string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm()&0077 == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s is readable by other users (mode %04o); run: chmod 600 %s", path, info.Mode().Perm(), path)
+}
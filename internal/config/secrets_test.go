@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEnsureAPIKeyPrecedence(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test commands need sh")
+	}
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		overrides map[string]string
+		want      string
+		source    string
+	}{
+		{"command over the file", nil, nil, "command-key", SourceCommand},
+		{"REKA_API_KEY over the command", map[string]string{legacyAPIKeyEnv: "reka-key"}, nil, "reka-key", "env " + legacyAPIKeyEnv},
+		{"BME_API_KEY over the command", map[string]string{"BME_API_KEY": "bme-key"}, nil, "bme-key", "env BME_API_KEY"},
+		{"flag over the command", nil, map[string]string{"api_key": "flag-key"}, "flag-key", SourceFlag},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := isolate(t)
+			content := `{"api_key":"file-key","api_key_command":"echo command-key","save_env_api_key":false}`
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Resolve(tt.overrides)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, err := EnsureAPIKey(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key != tt.want || cfg.Source("api_key") != tt.source {
+				t.Errorf("key = %q from %s, want %q from %s", key, cfg.Source("api_key"), tt.want, tt.source)
+			}
+		})
+	}
+}
+
+func TestAPIKeyCommandFailures(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test commands need sh")
+	}
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"echo leaked-key; exit 3", "api_key_command failed: exit status 3"},
+		{"echo locked >&2; exit 1", "locked"},
+		{"printf '\\n'", "printed no key"},
+		{"sleep 5", "timed out after 100ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			isolate(t)
+			cfg, err := Resolve(map[string]string{"api_key_command": tt.command, "api_key_command_timeout": "100ms"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = EnsureAPIKey(cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("EnsureAPIKey() = %v, want an error with %q", err, tt.want)
+			}
+			if strings.Contains(err.Error(), "leaked-key") {
+				t.Errorf("the error shows the output of the command: %v", err)
+			}
+		})
+	}
+}
+
+func TestSaveEnvAPIKey(t *testing.T) {
+	for _, save := range []bool{true, false} {
+		path := isolate(t)
+		content := `{"save_env_api_key":false}`
+		if save {
+			content = `{}`
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv(legacyAPIKeyEnv, "reka-key")
+
+		cfg, err := Resolve(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key, err := EnsureAPIKey(cfg); err != nil || key != "reka-key" {
+			t.Fatalf("EnsureAPIKey() = %q, %v", key, err)
+		}
+		file, err := Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved := file.APIKey == "reka-key"; saved != save {
+			t.Errorf("save_env_api_key %v: saved key = %q", save, file.APIKey)
+		}
+	}
+}
+
+func TestPermissionWarning(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes don't apply on Windows")
+	}
+	path := isolate(t)
+	if err := os.WriteFile(path, []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if warnings := cfg.Warnings(); len(warnings) != 0 {
+		t.Errorf("warnings = %v, want none for mode 0600", warnings)
+	}
+
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Resolve(nil); err != nil {
+		t.Fatal(err)
+	}
+	warnings := cfg.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "mode 0644") || !strings.Contains(warnings[0], "chmod 600 "+path) {
+		t.Errorf("warnings = %v, want one asking to chmod 600", warnings)
+	}
+}
@@ -0,0 +1,662 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/cassette"
+	"github.com/fboucher/be-my-eyes/internal/logging"
+)
+
+// Sources of a setting, from lowest to highest precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceFlag    = "flag"
+)
+
+// envPrefix starts the name of every environment variable setting
+const envPrefix = "BME_"
+
+// legacyAPIKeyEnv is the original API key variable, below BME_API_KEY
+const legacyAPIKeyEnv = "REKA_API_KEY"
+
+// DefaultKeybindings are the TUI actions that can be bound to another key,
+// with their default key
+var DefaultKeybindings = map[string]string{
+	"quit":          "q",
+	"switch":        "tab",
+	"filter":        "/",
+	"refresh":       "r",
+	"ask":           "a",
+	"upload":        "u",
+	"scope":         "h",
+	"video_history": "v",
+	"mark":          " ",
+	"batch_ask":     "A",
+	"batch":         "B",
+	"jobs":          "J",
+	"export":        "e",
+	"delete":        "D",
+	"search":        "s",
+	"logs":          "L",
+	"errors":        "E",
+	"palette":       "ctrl+p",
+	"menu":          "x",
+	"help":          "?",
+
+	// Question dialog
+	"question_submit":   "ctrl+s",
+	"question_refresh":  "ctrl+r",
+	"question_template": "ctrl+t",
+}
+
+// KeybindingScope returns where the key of an action is read: "question"
+// for the question dialog, "main" for the main view. Keys only conflict
+// within a scope.
+func KeybindingScope(action string) string {
+	if strings.HasPrefix(action, "question_") {
+		return "question"
+	}
+	return "main"
+}
+
+// reservedKeys are the keys of each scope that can't be rebound, with what
+// they do
+var reservedKeys = map[string]map[string]string{
+	"main": {
+		"up": "navigation", "down": "navigation", "k": "navigation", "j": "navigation",
+		"enter": "select", "esc": "clear filter", "ctrl+c": "force quit",
+	},
+	"question": {
+		"esc": "cancel", "enter": "new line", "tab": "indent", "ctrl+c": "force quit",
+	},
+}
+
+// Default returns the built-in configuration, the lowest layer
+func Default() *Config {
+	dbPath := "history.db"
+	if dir, err := DataDir(); err == nil {
+		dbPath = filepath.Join(dir, "history.db")
+	}
+	cacheDir := "cache"
+	if dir, err := CacheDir(); err == nil {
+		cacheDir = dir
+	}
+	logFile := appName + ".log"
+	if dir, err := StateDir(); err == nil {
+		logFile = filepath.Join(dir, logFile)
+	}
+
+	index := true
+	cacheAnswers := true
+	saveEnvKey := true
+	keybindings := make(map[string]string, len(DefaultKeybindings))
+	for action, key := range DefaultKeybindings {
+		keybindings[action] = key
+	}
+
+	return &Config{
+		APIKeyCommandTimeout: Duration(DefaultAPIKeyCommandTimeout),
+		SaveEnvAPIKey:        &saveEnvKey,
+		MaxConcurrentJobs:    DefaultMaxConcurrentJobs,
+		API: &APIConfig{
+			BaseURL:      api.DefaultBaseURL,
+			Timeout:      Duration(api.DefaultTimeout),
+			RateLimits:   map[string]string{},
+			QuotaWarning: 80,
+		},
+		Storage: &StorageConfig{DBPath: dbPath, CacheDir: cacheDir},
+		UI: &UIConfig{
+			LayoutSplit: 0.4,
+			Theme:       &ThemeConfig{Name: ThemeDark},
+			Keybindings: keybindings,
+		},
+		Defaults: &DefaultsConfig{UploadIndex: &index},
+		Server:   &ServerConfig{Addr: "127.0.0.1:8787"},
+		Cache:    &CacheConfig{Enabled: &cacheAnswers, TTL: Duration(24 * time.Hour)},
+		Log:      &LogConfig{Level: logging.LevelWarn, File: logFile, MaxSize: 5, MaxFiles: 3},
+	}
+}
+
+// setting is one entry of the schema: how to read and write it as text
+type setting struct {
+	key    string
+	secret bool
+	get    func(*Config) string
+	set    func(*Config, string) error
+}
+
+// env returns the environment variable of a setting: BME_ and the key in
+// upper case with dots as underscores, e.g. BME_API_TIMEOUT
+func (s setting) env() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(s.key, ".", "_"))
+}
+
+// settings returns the schema. Sections are expected to be set, as they are
+// on the configuration from Default.
+func settings() []setting {
+	list := []setting{
+		{key: "api_key", secret: true,
+			get: func(c *Config) string { return c.APIKey },
+			set: func(c *Config, v string) error { c.APIKey = v; return nil }},
+		{key: "api_key_command",
+			get: func(c *Config) string { return c.APIKeyCommand },
+			set: func(c *Config, v string) error { c.APIKeyCommand = v; return nil }},
+		{key: "api_key_command_timeout",
+			get: func(c *Config) string { return time.Duration(c.APIKeyCommandTimeout).String() },
+			set: func(c *Config, v string) error {
+				d, err := time.ParseDuration(v)
+				c.APIKeyCommandTimeout = Duration(d)
+				return err
+			}},
+		{key: "save_env_api_key",
+			get: func(c *Config) string { return strconv.FormatBool(*c.SaveEnvAPIKey) },
+			set: func(c *Config, v string) error {
+				b, err := strconv.ParseBool(v)
+				c.SaveEnvAPIKey = &b
+				return err
+			}},
+		{key: "api.base_url",
+			get: func(c *Config) string { return c.API.BaseURL },
+			set: func(c *Config, v string) error { c.API.BaseURL = v; return nil }},
+		{key: "api.timeout",
+			get: func(c *Config) string { return time.Duration(c.API.Timeout).String() },
+			set: func(c *Config, v string) error {
+				d, err := time.ParseDuration(v)
+				c.API.Timeout = Duration(d)
+				return err
+			}},
+		{key: "api.rate_limit",
+			get: func(c *Config) string { return c.API.RateLimit },
+			set: func(c *Config, v string) error { c.API.RateLimit = v; return nil }},
+		{key: "api.daily_quota",
+			get: func(c *Config) string { return strconv.Itoa(c.API.DailyQuota) },
+			set: func(c *Config, v string) error {
+				n, err := strconv.Atoi(v)
+				c.API.DailyQuota = n
+				return err
+			}},
+		{key: "api.quota_warning",
+			get: func(c *Config) string { return strconv.Itoa(c.API.QuotaWarning) },
+			set: func(c *Config, v string) error {
+				n, err := strconv.Atoi(v)
+				c.API.QuotaWarning = n
+				return err
+			}},
+		{key: "api.cassette",
+			get: func(c *Config) string { return c.API.Cassette },
+			set: func(c *Config, v string) error { c.API.Cassette = v; return nil }},
+		{key: "api.cassette_mode",
+			get: func(c *Config) string { return c.API.CassetteMode },
+			set: func(c *Config, v string) error { c.API.CassetteMode = v; return nil }},
+		{key: "max_concurrent_jobs",
+			get: func(c *Config) string { return strconv.Itoa(c.MaxConcurrentJobs) },
+			set: func(c *Config, v string) error {
+				n, err := strconv.Atoi(v)
+				c.MaxConcurrentJobs = n
+				return err
+			}},
+		{key: "time_zone",
+			get: func(c *Config) string { return c.TimeZone },
+			set: func(c *Config, v string) error { c.TimeZone = v; return nil }},
+		{key: "profile",
+			get: func(c *Config) string { return c.ActiveProfile() },
+			set: func(c *Config, v string) error { c.Profile = profileName(v); return nil }},
+		{key: "storage.db_path",
+			get: func(c *Config) string { return c.Storage.DBPath },
+			set: func(c *Config, v string) error { c.Storage.DBPath = v; return nil }},
+		{key: "storage.cache_dir",
+			get: func(c *Config) string { return c.Storage.CacheDir },
+			set: func(c *Config, v string) error { c.Storage.CacheDir = v; return nil }},
+		{key: "ui.layout_split",
+			get: func(c *Config) string { return strconv.FormatFloat(c.UI.LayoutSplit, 'g', -1, 64) },
+			set: func(c *Config, v string) error {
+				f, err := strconv.ParseFloat(v, 64)
+				c.UI.LayoutSplit = f
+				return err
+			}},
+		{key: "ui.theme.name",
+			get: func(c *Config) string { return c.UI.Theme.Name },
+			set: func(c *Config, v string) error { c.UI.Theme.Name = v; return nil }},
+		{key: "ui.theme.accent",
+			get: func(c *Config) string { return c.UI.Theme.Accent },
+			set: func(c *Config, v string) error { c.UI.Theme.Accent = v; return nil }},
+		{key: "ui.theme.border",
+			get: func(c *Config) string { return c.UI.Theme.Border },
+			set: func(c *Config, v string) error { c.UI.Theme.Border = v; return nil }},
+		{key: "ui.theme.title",
+			get: func(c *Config) string { return c.UI.Theme.Title },
+			set: func(c *Config, v string) error { c.UI.Theme.Title = v; return nil }},
+		{key: "ui.theme.muted",
+			get: func(c *Config) string { return c.UI.Theme.Muted },
+			set: func(c *Config, v string) error { c.UI.Theme.Muted = v; return nil }},
+		{key: "ui.theme.focus",
+			get: func(c *Config) string { return c.UI.Theme.Focus },
+			set: func(c *Config, v string) error { c.UI.Theme.Focus = v; return nil }},
+		{key: "defaults.upload_index",
+			get: func(c *Config) string { return strconv.FormatBool(*c.Defaults.UploadIndex) },
+			set: func(c *Config, v string) error {
+				b, err := strconv.ParseBool(v)
+				c.Defaults.UploadIndex = &b
+				return err
+			}},
+		{key: "server.addr",
+			get: func(c *Config) string { return c.Server.Addr },
+			set: func(c *Config, v string) error { c.Server.Addr = v; return nil }},
+		{key: "cache.enabled",
+			get: func(c *Config) string { return strconv.FormatBool(*c.Cache.Enabled) },
+			set: func(c *Config, v string) error {
+				b, err := strconv.ParseBool(v)
+				c.Cache.Enabled = &b
+				return err
+			}},
+		{key: "cache.ttl",
+			get: func(c *Config) string { return time.Duration(c.Cache.TTL).String() },
+			set: func(c *Config, v string) error {
+				d, err := time.ParseDuration(v)
+				c.Cache.TTL = Duration(d)
+				return err
+			}},
+		{key: "log.level",
+			get: func(c *Config) string { return c.Log.Level },
+			set: func(c *Config, v string) error { c.Log.Level = strings.ToLower(v); return nil }},
+		{key: "log.file",
+			get: func(c *Config) string { return c.Log.File },
+			set: func(c *Config, v string) error { c.Log.File = v; return nil }},
+		{key: "log.max_size",
+			get: func(c *Config) string { return strconv.Itoa(c.Log.MaxSize) },
+			set: func(c *Config, v string) error {
+				n, err := strconv.Atoi(v)
+				c.Log.MaxSize = n
+				return err
+			}},
+		{key: "log.max_files",
+			get: func(c *Config) string { return strconv.Itoa(c.Log.MaxFiles) },
+			set: func(c *Config, v string) error {
+				n, err := strconv.Atoi(v)
+				c.Log.MaxFiles = n
+				return err
+			}},
+	}
+
+	for _, endpoint := range api.Endpoints {
+		endpoint := endpoint
+		list = append(list, setting{
+			key: "api.rate_limits." + endpoint,
+			get: func(c *Config) string { return c.API.RateLimits[endpoint] },
+			set: func(c *Config, v string) error {
+				if c.API.RateLimits == nil {
+					c.API.RateLimits = make(map[string]string)
+				}
+				c.API.RateLimits[endpoint] = v
+				return nil
+			},
+		})
+	}
+
+	actions := make([]string, 0, len(DefaultKeybindings))
+	for action := range DefaultKeybindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		action := action
+		list = append(list, setting{
+			key: "ui.keybindings." + action,
+			get: func(c *Config) string { return c.UI.Keybindings[action] },
+			set: func(c *Config, v string) error {
+				if c.UI.Keybindings == nil {
+					c.UI.Keybindings = make(map[string]string)
+				}
+				c.UI.Keybindings[action] = v
+				return nil
+			},
+		})
+	}
+
+	return list
+}
+
+// findSetting returns the schema entry of a key
+func findSetting(key string) (setting, bool) {
+	for _, s := range settings() {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// Resolve builds the effective configuration from its layers, each one
+// overriding the previous: built-in defaults, the config file, BME_*
+// environment variables, then overrides (key=value from command-line flags).
+// The result is validated.
+func Resolve(overrides map[string]string) (*Config, error) {
+	cfg := Default()
+	schema := settings()
+	cfg.sources = make(map[string]string, len(schema))
+	for _, s := range schema {
+		cfg.sources[s.key] = SourceDefault
+	}
+
+	// apply runs one layer and records which settings it changed
+	apply := func(source string, layer func() error) error {
+		before := make(map[string]string, len(schema))
+		for _, s := range schema {
+			before[s.key] = s.get(cfg)
+		}
+		if err := layer(); err != nil {
+			return err
+		}
+		for _, s := range schema {
+			if s.get(cfg) != before[s.key] {
+				cfg.sources[s.key] = source
+			}
+		}
+		return nil
+	}
+
+	// Config file
+	err := apply(SourceFile, func() error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := decode(data, cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		cfg.fillDefaults()
+		if warning := permissionWarning(path); warning != "" {
+			cfg.warnings = append(cfg.warnings, warning)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Selected profile, over the top-level account settings of the file
+	cfg.Profile = profileName(cfg.Profile)
+	name := cfg.Profile
+	if v, ok := os.LookupEnv(envPrefix + "PROFILE"); ok {
+		name = profileName(v)
+	}
+	if v, ok := overrides["profile"]; ok {
+		name = profileName(v)
+	}
+	if name != "" {
+		apply("profile "+name, func() error { cfg.applyProfile(name); return nil })
+	}
+
+	// Environment, with the original API key variable below its BME_ name.
+	// Both are the key of the default profile; a named profile keeps its own.
+	if v := os.Getenv(legacyAPIKeyEnv); v != "" && name == "" {
+		apply("env "+legacyAPIKeyEnv, func() error { cfg.APIKey = v; return nil })
+	}
+	for _, s := range schema {
+		v, ok := os.LookupEnv(s.env())
+		if !ok || (s.key == "api_key" && name != "") {
+			continue
+		}
+		if err := apply("env "+s.env(), func() error { return s.set(cfg, v) }); err != nil {
+			return nil, fmt.Errorf("invalid %s=%q: %w", s.env(), v, err)
+		}
+	}
+
+	// NO_COLOR picks the theme without colors, unless one is named
+	if noColor() && cfg.Source("ui.theme.name") == SourceDefault {
+		apply("env "+noColorEnv, func() error { cfg.UI.Theme.Name = ThemeMonochrome; return nil })
+	}
+
+	// Command-line overrides
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	cfg.overrides = make(map[string]string, len(overrides))
+	for _, key := range keys {
+		cfg.overrides[key] = overrides[key]
+		s, ok := findSetting(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown setting %q (see 'be-my-eyes config show --effective')", key)
+		}
+		if err := apply(SourceFlag, func() error { return s.set(cfg, overrides[key]) }); err != nil {
+			return nil, fmt.Errorf("invalid --set %s=%q: %w", key, overrides[key], err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Source returns the layer that set a setting: default, file, env NAME or
+// flag. It is empty for configurations that don't come from Resolve.
+func (c *Config) Source(key string) string {
+	return c.sources[key]
+}
+
+// Setting is a setting of the effective configuration, as shown by
+// config show --effective
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	Env    string
+}
+
+// Settings lists every setting of an effective configuration with where it
+// came from. Secrets are masked.
+func (c *Config) Settings() []Setting {
+	var list []Setting
+	for _, s := range settings() {
+		value := s.get(c)
+		if s.secret && value != "" {
+			value = "[REDACTED]"
+		}
+		list = append(list, Setting{Key: s.key, Value: value, Source: c.Source(s.key), Env: s.env()})
+	}
+	return list
+}
+
+// colorPattern matches an ANSI 256 color number or a #RGB/#RRGGBB color
+var colorPattern = regexp.MustCompile(`^([0-9]{1,3}|#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6})$`)
+
+// Validate checks every setting of an effective configuration and reports
+// all the problems at once, naming where each bad value came from
+func (c *Config) Validate() error {
+	var problems []string
+	report := func(key, format string, args ...interface{}) {
+		problem := fmt.Sprintf("%s: %s", key, fmt.Sprintf(format, args...))
+		if source := c.Source(key); source != "" && source != SourceDefault {
+			problem += fmt.Sprintf(" (set by %s)", source)
+		}
+		problems = append(problems, problem)
+	}
+
+	if c.APIKeyCommandTimeout <= 0 {
+		report("api_key_command_timeout", "must be a positive duration such as \"30s\", got %s", time.Duration(c.APIKeyCommandTimeout))
+	}
+	if u, err := url.Parse(c.API.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		report("api.base_url", "must be an http(s) URL, got %q", c.API.BaseURL)
+	}
+	if c.API.Timeout <= 0 {
+		report("api.timeout", "must be a positive duration such as \"30s\", got %s", time.Duration(c.API.Timeout))
+	}
+	if _, err := api.ParseRate(c.API.RateLimit); err != nil {
+		report("api.rate_limit", "%v", err)
+	}
+	endpoints := make([]string, 0, len(c.API.RateLimits))
+	for endpoint := range c.API.RateLimits {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		known := false
+		for _, e := range api.Endpoints {
+			known = known || e == endpoint
+		}
+		if !known {
+			report("api.rate_limits."+endpoint, "unknown endpoint, use one of %s", strings.Join(api.Endpoints, ", "))
+		} else if _, err := api.ParseRate(c.API.RateLimits[endpoint]); err != nil {
+			report("api.rate_limits."+endpoint, "%v", err)
+		}
+	}
+	switch c.API.CassetteMode {
+	case "", cassette.Record, cassette.Replay:
+	default:
+		report("api.cassette_mode", "must be %s, %s or empty, got %q", cassette.Record, cassette.Replay, c.API.CassetteMode)
+	}
+	if c.API.DailyQuota < 0 {
+		report("api.daily_quota", "must be 0 (no quota) or more, got %d", c.API.DailyQuota)
+	}
+	if c.API.QuotaWarning < 1 || c.API.QuotaWarning > 100 {
+		report("api.quota_warning", "must be a percentage between 1 and 100, got %d", c.API.QuotaWarning)
+	}
+	if c.MaxConcurrentJobs < 1 {
+		report("max_concurrent_jobs", "must be at least 1, got %d", c.MaxConcurrentJobs)
+	}
+	if _, err := c.Location(); err != nil {
+		report("time_zone", "unknown time zone %q, use an IANA name such as \"Europe/Paris\"", c.TimeZone)
+	}
+	if c.Profile != "" && c.Profiles[c.Profile] == nil {
+		if names := c.ProfileNames(); len(names) > 0 {
+			report("profile", "unknown profile %q, the config file defines: %s", c.Profile, strings.Join(names, ", "))
+		} else {
+			report("profile", "unknown profile %q, the config file defines no profiles", c.Profile)
+		}
+	}
+	if _, ok := c.Profiles[DefaultProfile]; ok {
+		report("profiles."+DefaultProfile, "%q is the name of the top-level settings, pick another profile name", DefaultProfile)
+	}
+	if strings.TrimSpace(c.Storage.DBPath) == "" {
+		report("storage.db_path", "must not be empty")
+	}
+	if strings.TrimSpace(c.Storage.CacheDir) == "" {
+		report("storage.cache_dir", "must not be empty")
+	}
+	if c.UI.LayoutSplit < 0.2 || c.UI.LayoutSplit > 0.8 {
+		report("ui.layout_split", "must be between 0.2 and 0.8, got %g", c.UI.LayoutSplit)
+	}
+
+	// checkColors reports the bad colors of a theme, empty being the
+	// color of the named theme
+	checkColors := func(prefix string, theme *ThemeConfig) {
+		for _, color := range []struct{ key, value string }{
+			{"accent", theme.Accent},
+			{"border", theme.Border},
+			{"title", theme.Title},
+			{"muted", theme.Muted},
+			{"focus", theme.Focus},
+		} {
+			if color.value == "" {
+				continue
+			}
+			n, err := strconv.Atoi(color.value)
+			if !colorPattern.MatchString(color.value) || (err == nil && n > 255) {
+				report(prefix+color.key, "must be an ANSI color number (0-255) or #RRGGBB, got %q", color.value)
+			}
+		}
+	}
+	if _, err := c.resolveTheme(c.UI.Theme.Name, nil); err != nil {
+		report("ui.theme.name", "%v", err)
+	}
+	checkColors("ui.theme.", c.UI.Theme)
+	themes := make([]string, 0, len(c.UI.Themes))
+	for name := range c.UI.Themes {
+		themes = append(themes, name)
+	}
+	sort.Strings(themes)
+	for _, name := range themes {
+		if _, ok := builtinTheme(name); ok {
+			report("ui.themes."+name, "%q is a built-in theme, pick another name", name)
+			continue
+		}
+		if c.UI.Themes[name] == nil {
+			report("ui.themes."+name, "must be an object of colors")
+			continue
+		}
+		if _, err := c.resolveTheme(name, nil); err != nil {
+			report("ui.themes."+name+".name", "%v", err)
+		}
+		checkColors("ui.themes."+name+".", c.UI.Themes[name])
+	}
+
+	boundTo := make(map[string]string)
+	actions := make([]string, 0, len(c.UI.Keybindings))
+	for action := range c.UI.Keybindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		key := c.UI.Keybindings[action]
+		if _, ok := DefaultKeybindings[action]; !ok {
+			report("ui.keybindings."+action, "unknown action")
+			continue
+		}
+		if key == "" {
+			report("ui.keybindings."+action, "must not be empty")
+			continue
+		}
+		if key == "space" {
+			key = " "
+		}
+		scope := KeybindingScope(action)
+		if does, ok := reservedKeys[scope][key]; ok {
+			report("ui.keybindings."+action, "key %q is reserved for %s", key, does)
+			continue
+		}
+		if scope != "main" && len([]rune(key)) == 1 {
+			report("ui.keybindings."+action, "key %q would be typed into the dialog, use ctrl+ or alt+", key)
+			continue
+		}
+		if other, ok := boundTo[scope+" "+key]; ok {
+			report("ui.keybindings."+action, "key %q is already bound to %s", key, other)
+			continue
+		}
+		boundTo[scope+" "+key] = action
+	}
+
+	if strings.TrimSpace(c.Server.Addr) == "" {
+		report("server.addr", "must not be empty")
+	}
+	if c.Cache.TTL <= 0 {
+		report("cache.ttl", "must be a positive duration such as \"24h\", got %s", time.Duration(c.Cache.TTL))
+	}
+	if !logging.ValidLevel(c.Log.Level) {
+		report("log.level", "must be debug, info, warn, error or off, got %q", c.Log.Level)
+	}
+	if strings.TrimSpace(c.Log.File) == "" {
+		report("log.file", "must not be empty")
+	}
+	if c.Log.MaxSize < 1 {
+		report("log.max_size", "must be at least 1 (megabyte), got %d", c.Log.MaxSize)
+	}
+	if c.Log.MaxFiles < 0 {
+		report("log.max_files", "must not be negative, got %d", c.Log.MaxFiles)
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolate points the config file and the XDG directories to a temporary
+// directory and clears the environment settings. It returns the path of the
+// config file, which doesn't exist yet.
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(name, envPrefix) || name == legacyAPIKeyEnv || name == noColorEnv {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+	for _, env := range []string{"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_CACHE_HOME", "XDG_STATE_HOME"} {
+		t.Setenv(env, filepath.Join(dir, strings.ToLower(env)))
+	}
+	path := filepath.Join(dir, "config.json")
+	t.Setenv("BME_CONFIG", path)
+	return path
+}
+
+// writeConfig writes a config file for Resolve
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+	if err := os.WriteFile(isolate(t), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// valueOf returns a setting of an effective configuration and its source
+func valueOf(t *testing.T, cfg *Config, key string) (string, string) {
+	t.Helper()
+	for _, s := range cfg.Settings() {
+		if s.Key == key {
+			return s.Value, s.Source
+		}
+	}
+	t.Fatalf("no setting %s", key)
+	return "", ""
+}
+
+func TestResolveLayers(t *testing.T) {
+	writeConfig(t, `{"api_key":"file-key","api":{"timeout":"10s","rate_limit":"5/m"},"log":{"level":"info"}}`)
+	t.Setenv("BME_API_TIMEOUT", "20s")
+	t.Setenv("BME_LOG_LEVEL", "debug")
+
+	cfg, err := Resolve(map[string]string{"api.timeout": "30s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key, value, source string
+	}{
+		{"api_key", "[REDACTED]", SourceFile},
+		{"api.timeout", "30s", SourceFlag},
+		{"log.level", "debug", "env BME_LOG_LEVEL"},
+		{"api.rate_limit", "5/m", SourceFile},
+		{"server.addr", "127.0.0.1:8787", SourceDefault},
+	}
+	for _, tt := range tests {
+		value, source := valueOf(t, cfg, tt.key)
+		if value != tt.value || source != tt.source {
+			t.Errorf("%s = %q from %s, want %q from %s", tt.key, value, source, tt.value, tt.source)
+		}
+	}
+	if time.Duration(cfg.API.Timeout) != 30*time.Second {
+		t.Errorf("API.Timeout = %s, want 30s", time.Duration(cfg.API.Timeout))
+	}
+}
+
+func TestResolveNullSections(t *testing.T) {
+	tests := []string{
+		`{"api":null}`,
+		`{"cache":null}`,
+		`{"cache":{"enabled":null,"ttl":null}}`,
+		`{"defaults":{"upload_index":null}}`,
+		`{"defaults":null,"storage":null,"server":null,"log":null}`,
+		`{"ui":null}`,
+		`{"ui":{"theme":null}}`,
+		`{"save_env_api_key":null}`,
+		`{"api":{"timeout":null}}`,
+	}
+	for _, content := range tests {
+		t.Run(content, func(t *testing.T) {
+			writeConfig(t, content)
+
+			cfg, err := Resolve(nil)
+			if err != nil {
+				t.Fatalf("Resolve() = %v", err)
+			}
+			for _, s := range cfg.Settings() {
+				if s.Source != SourceDefault {
+					t.Errorf("%s set by %s, want the default", s.Key, s.Source)
+				}
+			}
+			if !*cfg.Defaults.UploadIndex || !*cfg.Cache.Enabled || !*cfg.SaveEnvAPIKey {
+				t.Error("null optional values should fall back to their defaults")
+			}
+		})
+	}
+}
+
+func TestResolveReportsInvalidSettings(t *testing.T) {
+	writeConfig(t, `{"api":{"timeout":"-1s"}}`)
+	t.Setenv("BME_API_RATE_LIMIT", "lots")
+
+	_, err := Resolve(nil)
+	if err == nil {
+		t.Fatal("Resolve() should fail")
+	}
+	for _, want := range []string{"api.timeout", "(set by file)", "api.rate_limit", "(set by env BME_API_RATE_LIMIT)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+
+	if _, err := Resolve(map[string]string{"api.nope": "1"}); err == nil {
+		t.Error("Resolve() should reject unknown settings")
+	}
+}
+
+func TestEnsureAPIKeySavesEnvKeyWithNullSetting(t *testing.T) {
+	writeConfig(t, `{"save_env_api_key":null}`)
+	t.Setenv(legacyAPIKeyEnv, "env-key")
+
+	cfg, err := Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := EnsureAPIKey(cfg)
+	if err != nil || key != "env-key" {
+		t.Fatalf("EnsureAPIKey() = %q, %v", key, err)
+	}
+
+	file, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.APIKey != "env-key" {
+		t.Errorf("saved key = %q, want env-key", file.APIKey)
+	}
+}
+
+func TestEnvKeysOnlyForDefaultProfile(t *testing.T) {
+	tests := []struct {
+		env       string
+		profile   string
+		overrides map[string]string
+		want      string
+		source    string
+	}{
+		{legacyAPIKeyEnv, "", nil, "env-key", "env " + legacyAPIKeyEnv},
+		{legacyAPIKeyEnv, "work", nil, "work-key", "profile work"},
+		{legacyAPIKeyEnv, "empty", nil, "", "profile empty"},
+		{"BME_API_KEY", "", nil, "env-key", "env BME_API_KEY"},
+		{"BME_API_KEY", "work", nil, "work-key", "profile work"},
+		{"BME_API_KEY", "empty", nil, "", "profile empty"},
+		{"BME_API_KEY", "work", map[string]string{"api_key": "flag-key"}, "flag-key", SourceFlag},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env+" "+tt.profile, func(t *testing.T) {
+			writeConfig(t, `{"api_key":"top-key","profiles":{"work":{"api_key":"work-key"},"empty":{}}}`)
+			t.Setenv(tt.env, "env-key")
+
+			overrides := map[string]string{}
+			for key, value := range tt.overrides {
+				overrides[key] = value
+			}
+			if tt.profile != "" {
+				overrides["profile"] = tt.profile
+			}
+			cfg, err := Resolve(overrides)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.APIKey != tt.want || cfg.Source("api_key") != tt.source {
+				t.Errorf("api_key = %q from %s, want %q from %s", cfg.APIKey, cfg.Source("api_key"), tt.want, tt.source)
+			}
+		})
+	}
+}
+
+func TestEnsureAPIKeyNeverSavesEnvKeyIntoProfile(t *testing.T) {
+	writeConfig(t, `{"profiles":{"work":{}}}`)
+	t.Setenv(legacyAPIKeyEnv, "env-key")
+
+	cfg, err := Resolve(map[string]string{"profile": "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnsureAPIKey(cfg); err != ErrNoAPIKey {
+		t.Fatalf("EnsureAPIKey() = %v, want ErrNoAPIKey", err)
+	}
+
+	file, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.APIKey != "" || file.Profiles["work"].APIKey != "" {
+		t.Errorf("the env key was saved: top %q, work %q", file.APIKey, file.Profiles["work"].APIKey)
+	}
+}
+
+func TestResolveSetsIntoNullMaps(t *testing.T) {
+	writeConfig(t, `{"api":{"rate_limits":null},"ui":{"keybindings":null}}`)
+	t.Setenv("BME_API_RATE_LIMITS_CHAT", "5/m")
+
+	cfg, err := Resolve(map[string]string{"ui.keybindings.search": "ctrl+f"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, source := valueOf(t, cfg, "api.rate_limits.chat"); value != "5/m" || source != "env BME_API_RATE_LIMITS_CHAT" {
+		t.Errorf("api.rate_limits.chat = %q from %s", value, source)
+	}
+	if value, source := valueOf(t, cfg, "ui.keybindings.search"); value != "ctrl+f" || source != SourceFlag {
+		t.Errorf("ui.keybindings.search = %q from %s", value, source)
+	}
+}
+
+func TestCassettePathDefaultsToCacheDir(t *testing.T) {
+	isolate(t)
+	cfg, err := Resolve(map[string]string{"api.cassette_mode": "replay"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(os.Getenv("XDG_CACHE_HOME"), appName, "cassette.json")
+	if got := cfg.CassettePath(); got != want {
+		t.Errorf("CassettePath() = %q, want %q", got, want)
+	}
+
+	cfg, err = Resolve(map[string]string{"storage.cache_dir": "/tmp/bme", "api.cassette": "demo.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.CassettePath(); got != "demo.json" {
+		t.Errorf("CassettePath() = %q, want the api.cassette setting", got)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Built-in themes
+const (
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeMonochrome   = "monochrome"
+)
+
+// noColorEnv turns colors off when set, unless a theme is named
+// (https://no-color.org)
+const noColorEnv = "NO_COLOR"
+
+// builtinThemes are the themes that need no configuration, in the order
+// they are offered. Monochrome has no colors at all.
+var builtinThemes = []struct {
+	name  string
+	theme ThemeConfig
+}{
+	{ThemeDark, ThemeConfig{Accent: "205", Border: "240", Title: "230", Muted: "241", Focus: "69"}},
+	{ThemeLight, ThemeConfig{Accent: "161", Border: "248", Title: "17", Muted: "243", Focus: "26"}},
+	{ThemeHighContrast, ThemeConfig{Accent: "11", Border: "15", Title: "15", Muted: "252", Focus: "14"}},
+	{ThemeMonochrome, ThemeConfig{}},
+}
+
+// builtinTheme returns the colors of a built-in theme
+func builtinTheme(name string) (ThemeConfig, bool) {
+	for _, t := range builtinThemes {
+		if t.name == name {
+			return t.theme, true
+		}
+	}
+	return ThemeConfig{}, false
+}
+
+// ThemeNames returns the built-in themes, then the themes of ui.themes
+// sorted by name
+func (c *Config) ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes)+len(c.UI.Themes))
+	for _, t := range builtinThemes {
+		names = append(names, t.name)
+	}
+	custom := make([]string, 0, len(c.UI.Themes))
+	for name := range c.UI.Themes {
+		if _, ok := builtinTheme(name); !ok {
+			custom = append(custom, name)
+		}
+	}
+	sort.Strings(custom)
+	return append(names, custom...)
+}
+
+// Theme returns the colors of the TUI: the theme named by ui.theme.name,
+// with the ui.theme colors that are set over it. An empty color is the
+// terminal's own.
+func (c *Config) Theme() ThemeConfig {
+	theme, err := c.resolveTheme(c.UI.Theme.Name, nil)
+	if err != nil {
+		theme, _ = builtinTheme(ThemeDark)
+	}
+	theme.Name = c.UI.Theme.Name
+	return theme.over(c.UI.Theme)
+}
+
+// resolveTheme returns the colors of a built-in theme or of a theme of
+// ui.themes, which starts from the theme it names (dark by default)
+func (c *Config) resolveTheme(name string, seen []string) (ThemeConfig, error) {
+	if name == "" {
+		name = ThemeDark
+	}
+	if theme, ok := builtinTheme(name); ok {
+		return theme, nil
+	}
+	custom, ok := c.UI.Themes[name]
+	if !ok || custom == nil {
+		return ThemeConfig{}, fmt.Errorf("unknown theme %q, use one of %s", name, strings.Join(c.ThemeNames(), ", "))
+	}
+	for _, s := range seen {
+		if s == name {
+			return ThemeConfig{}, fmt.Errorf("themes start from each other: %s", strings.Join(append(seen, name), " -> "))
+		}
+	}
+	base, err := c.resolveTheme(custom.Name, append(seen, name))
+	if err != nil {
+		return ThemeConfig{}, err
+	}
+	return base.over(custom), nil
+}
+
+// over returns the theme with the colors set in overrides replacing its own
+func (t ThemeConfig) over(overrides *ThemeConfig) ThemeConfig {
+	if overrides == nil {
+		return t
+	}
+	for _, color := range []struct{ to, from *string }{
+		{&t.Accent, &overrides.Accent},
+		{&t.Border, &overrides.Border},
+		{&t.Title, &overrides.Title},
+		{&t.Muted, &overrides.Muted},
+		{&t.Focus, &overrides.Focus},
+	} {
+		if *color.from != "" {
+			*color.to = *color.from
+		}
+	}
+	return t
+}
+
+// Colorless reports whether the theme uses no color, as monochrome
+func (t ThemeConfig) Colorless() bool {
+	return t.Accent == "" && t.Border == "" && t.Title == "" && t.Muted == "" && t.Focus == ""
+}
+
+// noColor reports whether NO_COLOR asks for no colors
+func noColor() bool {
+	return os.Getenv(noColorEnv) != ""
+}
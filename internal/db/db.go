@@ -3,8 +3,10 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/fboucher/be-my-eyes/internal/models"
@@ -16,28 +18,11 @@ type DB struct {
 	conn *sql.DB
 }
 
-// dbPath returns the path to the SQLite database file
-func dbPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	configDir := filepath.Join(homeDir, ".config", "be-my-eyes")
-
-	// Create config directory if it doesn't exist
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create config directory: %w", err)
-	}
-
-	return filepath.Join(configDir, "history.db"), nil
-}
-
-// Open opens the SQLite database and initializes the schema
-func Open() (*DB, error) {
-	path, err := dbPath()
-	if err != nil {
-		return nil, err
+// Open opens the SQLite database at path and initializes the schema. The
+// parent directory is created if needed.
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
 	conn, err := sql.Open("sqlite3", path)
@@ -80,9 +65,48 @@ func (db *DB) initSchema() error {
 		FOREIGN KEY (query_id) REFERENCES query_history(id) ON DELETE CASCADE
 	);
 
+	CREATE TABLE IF NOT EXISTS prompt_templates (
+		name TEXT PRIMARY KEY,
+		text TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS search_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		query TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS search_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		search_id INTEGER NOT NULL,
+		rank INTEGER NOT NULL,
+		video_id TEXT NOT NULL,
+		start_time REAL NOT NULL,
+		end_time REAL NOT NULL,
+		score REAL NOT NULL,
+		caption TEXT NOT NULL,
+		FOREIGN KEY (search_id) REFERENCES search_history(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS api_calls (
+		day TEXT NOT NULL,
+		endpoint TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (day, endpoint)
+	);
+
+	CREATE TABLE IF NOT EXISTS answer_cache (
+		cache_key TEXT PRIMARY KEY,
+		query_id INTEGER NOT NULL,
+		fingerprint TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_query_history_video_id ON query_history(video_id);
 	CREATE INDEX IF NOT EXISTS idx_query_history_created_at ON query_history(created_at);
 	CREATE INDEX IF NOT EXISTS idx_video_clips_query_id ON video_clips(query_id);
+	CREATE INDEX IF NOT EXISTS idx_search_results_search_id ON search_results(search_id);
 	`
 
 	_, err := db.conn.Exec(query)
@@ -98,17 +122,18 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-// SaveQuery saves a query and its result to the database along with video clips
-func (db *DB) SaveQuery(videoID, videoTitle, question, answer string, videoClips []models.VideoClip, errMsg *string, status string) error {
+// SaveQuery saves a query and its result to the database along with video
+// clips, and returns the ID of the query
+func (db *DB) SaveQuery(videoID, videoTitle, question, answer string, videoClips []models.VideoClip, errMsg *string, status string) (int, error) {
 	// Start a transaction
 	tx, err := db.conn.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer func() {
 		if rerr := tx.Rollback(); rerr != nil && rerr != sql.ErrTxDone {
 			// rollback failed; log and move on
-			fmt.Printf("transaction rollback error: %v\n", rerr)
+			slog.Warn("transaction rollback failed", "error", rerr)
 		}
 	}()
 
@@ -120,13 +145,13 @@ func (db *DB) SaveQuery(videoID, videoTitle, question, answer string, videoClips
 
 	result, err := tx.Exec(query, videoID, videoTitle, question, answer, errMsg, status, time.Now())
 	if err != nil {
-		return fmt.Errorf("failed to save query: %w", err)
+		return 0, fmt.Errorf("failed to save query: %w", err)
 	}
 
 	// Get the inserted query ID
 	queryID, err := result.LastInsertId()
 	if err != nil {
-		return fmt.Errorf("failed to get query ID: %w", err)
+		return 0, fmt.Errorf("failed to get query ID: %w", err)
 	}
 
 	// Insert video clips if any
@@ -139,17 +164,17 @@ func (db *DB) SaveQuery(videoID, videoTitle, question, answer string, videoClips
 		for _, clip := range videoClips {
 			_, err := tx.Exec(clipQuery, queryID, clip.ClipID, clip.StartTime, clip.EndTime, clip.Info)
 			if err != nil {
-				return fmt.Errorf("failed to save video clip: %w", err)
+				return 0, fmt.Errorf("failed to save video clip: %w", err)
 			}
 		}
 	}
 
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return int(queryID), nil
 }
 
 // GetAllHistory retrieves all query history ordered by creation time (newest first)
@@ -241,6 +266,86 @@ func (db *DB) GetHistoryByVideoID(videoID string) ([]models.QueryHistory, error)
 	return history, nil
 }
 
+// GetQueryByID retrieves one query and its video clips
+func (db *DB) GetQueryByID(id int) (*models.QueryHistory, error) {
+	query := `
+	SELECT id, video_id, video_title, question, answer, error, status, created_at
+	FROM query_history
+	WHERE id = ?
+	`
+
+	var h models.QueryHistory
+	var errMsg sql.NullString
+
+	err := db.conn.QueryRow(query, id).Scan(&h.ID, &h.VideoID, &h.VideoTitle, &h.Question, &h.Answer, &errMsg, &h.Status, &h.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("query %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+
+	if errMsg.Valid {
+		h.Error = &errMsg.String
+	}
+
+	clips, err := db.getVideoClips(h.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load video clips: %w", err)
+	}
+	h.VideoClips = clips
+
+	return &h, nil
+}
+
+// SearchHistory retrieves the queries whose question or answer contains text,
+// optionally limited to one video, newest first
+func (db *DB) SearchHistory(text, videoID string) ([]models.QueryHistory, error) {
+	query := `
+	SELECT id, video_id, video_title, question, answer, error, status, created_at
+	FROM query_history
+	WHERE (question LIKE ? ESCAPE '\' OR answer LIKE ? ESCAPE '\') AND (? = '' OR video_id = ?)
+	ORDER BY created_at DESC
+	`
+
+	// % and _ in the text are matched literally
+	escaper := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	pattern := "%" + escaper.Replace(text) + "%"
+	rows, err := db.conn.Query(query, pattern, pattern, videoID, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []models.QueryHistory
+	for rows.Next() {
+		var h models.QueryHistory
+		var errMsg sql.NullString
+
+		if err := rows.Scan(&h.ID, &h.VideoID, &h.VideoTitle, &h.Question, &h.Answer, &errMsg, &h.Status, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if errMsg.Valid {
+			h.Error = &errMsg.String
+		}
+
+		clips, err := db.getVideoClips(h.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load video clips: %w", err)
+		}
+		h.VideoClips = clips
+
+		history = append(history, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return history, nil
+}
+
 // getVideoClips retrieves video clips for a specific query
 func (db *DB) getVideoClips(queryID int) ([]models.VideoClip, error) {
 	query := `
@@ -271,3 +376,296 @@ func (db *DB) getVideoClips(queryID int) ([]models.VideoClip, error) {
 
 	return clips, nil
 }
+
+// SavePromptTemplate creates or replaces a prompt template
+func (db *DB) SavePromptTemplate(name, text string) error {
+	query := `
+	INSERT INTO prompt_templates (name, text, created_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(name) DO UPDATE SET text = excluded.text
+	`
+
+	if _, err := db.conn.Exec(query, name, text, time.Now()); err != nil {
+		return fmt.Errorf("failed to save prompt template: %w", err)
+	}
+
+	return nil
+}
+
+// GetPromptTemplates retrieves the saved prompt templates ordered by name
+func (db *DB) GetPromptTemplates() ([]models.PromptTemplate, error) {
+	query := `
+	SELECT name, text, created_at
+	FROM prompt_templates
+	ORDER BY name ASC
+	`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []models.PromptTemplate
+	for rows.Next() {
+		var t models.PromptTemplate
+		if err := rows.Scan(&t.Name, &t.Text, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt template: %w", err)
+		}
+		templates = append(templates, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt template rows: %w", err)
+	}
+
+	return templates, nil
+}
+
+// DeletePromptTemplate removes a saved prompt template
+func (db *DB) DeletePromptTemplate(name string) error {
+	result, err := db.conn.Exec(`DELETE FROM prompt_templates WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete prompt template: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("prompt template %q not found", name)
+	}
+
+	return nil
+}
+
+// DeleteHistoryByVideoID removes the queries about a video with their clips
+// and cached answers, and the video's search results, returning how many
+// queries were removed. Saved searches left without results go too.
+func (db *DB) DeleteHistoryByVideoID(videoID string) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rerr := tx.Rollback(); rerr != nil && rerr != sql.ErrTxDone {
+			// rollback failed; log and move on
+			slog.Warn("transaction rollback failed", "error", rerr)
+		}
+	}()
+
+	// Foreign keys aren't enforced, so the clips and cached answers are
+	// removed explicitly
+	_, err = tx.Exec(`DELETE FROM video_clips WHERE query_id IN (SELECT id FROM query_history WHERE video_id = ?)`, videoID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete video clips: %w", err)
+	}
+	_, err = tx.Exec(`DELETE FROM answer_cache WHERE query_id IN (SELECT id FROM query_history WHERE video_id = ?)`, videoID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete cached answers: %w", err)
+	}
+
+	// Searches that only found this video are emptied, so they go with it
+	_, err = tx.Exec(`
+	DELETE FROM search_history
+	WHERE id IN (SELECT search_id FROM search_results WHERE video_id = ?)
+	AND id NOT IN (SELECT search_id FROM search_results WHERE video_id != ?)
+	`, videoID, videoID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete searches: %w", err)
+	}
+	_, err = tx.Exec(`DELETE FROM search_results WHERE video_id = ?`, videoID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete search results: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM query_history WHERE video_id = ?`, videoID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete query history: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted queries: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// SaveSearch saves a search and its results, in rank order
+func (db *DB) SaveSearch(query string, results []models.SearchResult) (*models.SearchHistory, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rerr := tx.Rollback(); rerr != nil && rerr != sql.ErrTxDone {
+			// rollback failed; log and move on
+			slog.Warn("transaction rollback failed", "error", rerr)
+		}
+	}()
+
+	createdAt := time.Now()
+	result, err := tx.Exec(`INSERT INTO search_history (query, created_at) VALUES (?, ?)`, query, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save search: %w", err)
+	}
+	searchID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get search ID: %w", err)
+	}
+
+	resultQuery := `
+	INSERT INTO search_results (search_id, rank, video_id, start_time, end_time, score, caption)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	for i, r := range results {
+		if _, err := tx.Exec(resultQuery, searchID, i, r.VideoID, r.StartTime, r.EndTime, r.Score, r.Caption); err != nil {
+			return nil, fmt.Errorf("failed to save search result: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &models.SearchHistory{ID: int(searchID), Query: query, CreatedAt: createdAt, Results: results}, nil
+}
+
+// GetSearches retrieves the most recent saved searches with their results,
+// newest first
+func (db *DB) GetSearches(limit int) ([]models.SearchHistory, error) {
+	rows, err := db.conn.Query(`SELECT id, query, created_at FROM search_history ORDER BY created_at DESC, id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query searches: %w", err)
+	}
+	defer rows.Close()
+
+	var searches []models.SearchHistory
+	for rows.Next() {
+		var s models.SearchHistory
+		if err := rows.Scan(&s.ID, &s.Query, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan search row: %w", err)
+		}
+		searches = append(searches, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search rows: %w", err)
+	}
+
+	for i := range searches {
+		results, err := db.getSearchResults(searches[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		searches[i].Results = results
+	}
+
+	return searches, nil
+}
+
+// getSearchResults retrieves the results of a saved search in rank order
+func (db *DB) getSearchResults(searchID int) ([]models.SearchResult, error) {
+	query := `
+	SELECT video_id, start_time, end_time, score, caption
+	FROM search_results
+	WHERE search_id = ?
+	ORDER BY rank
+	`
+
+	rows, err := db.conn.Query(query, searchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query search results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var r models.SearchResult
+		if err := rows.Scan(&r.VideoID, &r.StartTime, &r.EndTime, &r.Score, &r.Caption); err != nil {
+			return nil, fmt.Errorf("failed to scan search result row: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search result rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// dayFormat is how the call ledger writes days, in local time
+const dayFormat = "2006-01-02"
+
+// RecordAPICall counts a call to an API endpoint in today's ledger
+func (db *DB) RecordAPICall(endpoint string) error {
+	query := `
+	INSERT INTO api_calls (day, endpoint, count) VALUES (?, ?, 1)
+	ON CONFLICT(day, endpoint) DO UPDATE SET count = count + 1
+	`
+	if _, err := db.conn.Exec(query, time.Now().Format(dayFormat), endpoint); err != nil {
+		return fmt.Errorf("failed to record API call: %w", err)
+	}
+	return nil
+}
+
+// GetAPICalls returns the number of API calls made on a day, per endpoint
+func (db *DB) GetAPICalls(day time.Time) (map[string]int, error) {
+	rows, err := db.conn.Query(`SELECT endpoint, count FROM api_calls WHERE day = ?`, day.Format(dayFormat))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API calls: %w", err)
+	}
+	defer rows.Close()
+
+	calls := make(map[string]int)
+	for rows.Next() {
+		var endpoint string
+		var count int
+		if err := rows.Scan(&endpoint, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan API call row: %w", err)
+		}
+		calls[endpoint] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API call rows: %w", err)
+	}
+
+	return calls, nil
+}
+
+// GetCacheEntry retrieves the answer cache entry of a key, nil when there is
+// none
+func (db *DB) GetCacheEntry(key string) (*models.AnswerCacheEntry, error) {
+	query := `SELECT cache_key, query_id, fingerprint, created_at FROM answer_cache WHERE cache_key = ?`
+
+	var e models.AnswerCacheEntry
+	err := db.conn.QueryRow(query, key).Scan(&e.Key, &e.QueryID, &e.Fingerprint, &e.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query answer cache: %w", err)
+	}
+	return &e, nil
+}
+
+// SaveCacheEntry points a cache key to the query answering it, replacing
+// any previous answer
+func (db *DB) SaveCacheEntry(key string, queryID int, fingerprint string) error {
+	query := `
+	INSERT INTO answer_cache (cache_key, query_id, fingerprint, created_at) VALUES (?, ?, ?, ?)
+	ON CONFLICT(cache_key) DO UPDATE SET query_id = excluded.query_id, fingerprint = excluded.fingerprint, created_at = excluded.created_at
+	`
+	if _, err := db.conn.Exec(query, key, queryID, fingerprint, time.Now()); err != nil {
+		return fmt.Errorf("failed to save answer cache entry: %w", err)
+	}
+	return nil
+}
+
+// DeleteCacheEntry removes the answer cache entry of a key
+func (db *DB) DeleteCacheEntry(key string) error {
+	if _, err := db.conn.Exec(`DELETE FROM answer_cache WHERE cache_key = ?`, key); err != nil {
+		return fmt.Errorf("failed to delete answer cache entry: %w", err)
+	}
+	return nil
+}
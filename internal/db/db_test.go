@@ -0,0 +1,172 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+// openTestDB opens a history database in a temporary directory
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	database, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestGetHistoryByVideoID(t *testing.T) {
+	database := openTestDB(t)
+	day := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	queries := []struct {
+		videoID, question string
+		at                time.Time
+	}{
+		{"v1", "first", day},
+		{"v2", "other video", day.Add(time.Minute)},
+		{"v1", "third", day.Add(2 * time.Hour)},
+		{"v1", "second", day.Add(time.Hour)},
+	}
+	for _, q := range queries {
+		result, err := database.conn.Exec(`INSERT INTO query_history (video_id, video_title, question, answer, status, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			q.videoID, "Front door", q.question, "An answer", "success", q.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.question == "second" {
+			id, _ := result.LastInsertId()
+			if _, err := database.conn.Exec(`INSERT INTO video_clips (query_id, clip_id, start_time, end_time, info) VALUES (?, ?, ?, ?, ?)`,
+				id, "c1", 1.5, 3, "The cat"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	history, err := database.GetHistoryByVideoID("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, q := range history {
+		if q.VideoID != "v1" {
+			t.Errorf("query %q is about %s, want v1", q.Question, q.VideoID)
+		}
+		got = append(got, q.Question)
+	}
+	if len(got) != 3 || got[0] != "third" || got[1] != "second" || got[2] != "first" {
+		t.Fatalf("questions = %v, want newest first: [third second first]", got)
+	}
+	if clips := history[1].VideoClips; len(clips) != 1 || clips[0].Info != "The cat" || clips[0].StartTime != 1.5 {
+		t.Errorf("clips = %+v, want the clip of the second question", clips)
+	}
+
+	if history, err := database.GetHistoryByVideoID("unknown"); err != nil || len(history) != 0 {
+		t.Errorf("GetHistoryByVideoID(unknown) = %+v, %v, want nothing", history, err)
+	}
+}
+
+func TestSearchHistoryMatchesWildcardsLiterally(t *testing.T) {
+	database := openTestDB(t)
+	for _, q := range []string{"is it 100% sure?", "what is the file_name?", "plain question", `a \ backslash`} {
+		if _, err := database.SaveQuery("v1", "Video", q, "answer", nil, nil, "success"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"%", 1},
+		{"_", 1},
+		{`\`, 1},
+		{"question", 1},
+		{"is", 2},
+	}
+	for _, tt := range tests {
+		history, err := database.SearchHistory(tt.text, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != tt.want {
+			t.Errorf("SearchHistory(%q) = %d rows, want %d", tt.text, len(history), tt.want)
+		}
+	}
+}
+
+func TestDeleteHistoryByVideoID(t *testing.T) {
+	database := openTestDB(t)
+	clips := []models.VideoClip{{ClipID: "c1", StartTime: 1, EndTime: 2, Info: "door"}}
+	gone, err := database.SaveQuery("v1", "One", "who?", "a cat", clips, nil, "success")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.SaveQuery("v1", "One", "when?", "noon", nil, nil, "success"); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := database.SaveQuery("v2", "Two", "who?", "a dog", clips, nil, "success")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SaveCacheEntry("v1-key", gone, "f"); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SaveCacheEntry("v2-key", kept, "f"); err != nil {
+		t.Fatal(err)
+	}
+
+	both := []models.SearchResult{{VideoID: "v1", Caption: "cat"}, {VideoID: "v2", Caption: "dog"}}
+	for query, results := range map[string][]models.SearchResult{
+		"both":    both,
+		"only v1": {{VideoID: "v1", Caption: "cat"}},
+		"only v2": {{VideoID: "v2", Caption: "dog"}},
+	} {
+		if _, err := database.SaveSearch(query, results); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := database.DeleteHistoryByVideoID("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("deleted %d queries, want 2", n)
+	}
+
+	history, err := database.GetAllHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].ID != kept || len(history[0].VideoClips) != 1 {
+		t.Errorf("history = %+v, want only the v2 query with its clip", history)
+	}
+
+	if e, err := database.GetCacheEntry("v1-key"); err != nil || e != nil {
+		t.Errorf("v1 cache entry = %+v, %v, want none", e, err)
+	}
+	if e, err := database.GetCacheEntry("v2-key"); err != nil || e == nil {
+		t.Errorf("v2 cache entry = %+v, %v, want kept", e, err)
+	}
+
+	searches, err := database.GetSearches(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int{}
+	for _, s := range searches {
+		for _, r := range s.Results {
+			if r.VideoID == "v1" {
+				t.Errorf("search %q still has a v1 result", s.Query)
+			}
+		}
+		got[s.Query] = len(s.Results)
+	}
+	if len(got) != 2 || got["both"] != 1 || got["only v2"] != 1 {
+		t.Errorf("searches = %v, want both and only v2 with one result each", got)
+	}
+}
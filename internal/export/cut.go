@@ -0,0 +1,248 @@
+package export
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/wallclock"
+)
+
+// defaultFrameRate is the EDL timecode rate when no video gives one
+const defaultFrameRate = 30
+
+// Source is a video to cut: where to read it from and the clips to keep
+type Source struct {
+	Path     string // video URL or local file
+	Title    string
+	Duration float64   // seconds, 0 when unknown
+	FPS      float64   // 0 when unknown
+	Start    time.Time // when the video started, zero when unknown
+	Clips    []models.VideoClip
+}
+
+// clipText returns the text of a clip, prefixed with its wall-clock span
+// when the video start is known
+func (s Source) clipText(clip models.VideoClip, index int) string {
+	text := cueText(clip, index)
+	if s.Start.IsZero() {
+		return text
+	}
+	return wallclock.ClipSpan(s.Start, clip).String() + " " + text
+}
+
+// Options controls how clips become cuts
+type Options struct {
+	Padding   float64 // seconds added before and after each clip
+	Merge     bool    // merge clips of the same source that overlap once padded
+	FrameRate float64 // EDL timecode rate, 0 uses the first source's
+	WallClock bool    // prefix subtitle and chapter text with the wall-clock span
+}
+
+// Segment is a range of a source video to keep
+type Segment struct {
+	Source Source
+	Start  float64
+	End    float64
+	Info   string
+}
+
+// Export renders the clips of one or more videos in the given format and
+// returns the content with the number of clips or cuts written. Subtitle and
+// chapter formats take a single video and only use the WallClock option; the
+// comments of cut formats always have the wall-clock span when it is known.
+func Export(format Format, sources []Source, opts Options) (string, int, error) {
+	if len(sources) == 0 {
+		return "", 0, fmt.Errorf("nothing to export")
+	}
+
+	if !format.IsCut() {
+		if len(sources) > 1 {
+			return "", 0, fmt.Errorf("the %s format exports the clips of a single query", format)
+		}
+		clips, err := Clips(sources[0].Clips, sources[0].Duration)
+		if err != nil {
+			return "", 0, err
+		}
+		if opts.WallClock {
+			for i := range clips {
+				clips[i].Info = sources[0].clipText(clips[i], i)
+			}
+		}
+		content, err := Render(format, sources[0].Title, clips, sources[0].Duration)
+		return content, len(clips), err
+	}
+
+	segments, err := Segments(sources, opts)
+	if err != nil {
+		return "", 0, err
+	}
+
+	title := sources[0].Title
+	if len(sources) > 1 {
+		title = "highlights"
+	}
+
+	switch format {
+	case FFmpegScript:
+		return renderFFmpegScript(title, segments), len(segments), nil
+	case EDL:
+		frameRate := opts.FrameRate
+		if frameRate <= 0 {
+			frameRate = sources[0].FPS
+		}
+		return renderEDL(title, segments, frameRate), len(segments), nil
+	default:
+		return "", 0, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// Segments validates the clips of every source, pads them and optionally
+// merges the overlapping ones. Sources keep their order; the clips of a
+// source are sorted by start time.
+func Segments(sources []Source, opts Options) ([]Segment, error) {
+	if opts.Padding < 0 {
+		return nil, fmt.Errorf("padding can't be negative (%.1fs)", opts.Padding)
+	}
+
+	var segments []Segment
+	for _, source := range sources {
+		if source.Path == "" {
+			return nil, fmt.Errorf("no source file or URL for %q", source.Title)
+		}
+
+		clips, err := Clips(source.Clips, source.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source.Title, err)
+		}
+
+		var previous *Segment
+		for i, clip := range clips {
+			segment := Segment{
+				Source: source,
+				Start:  math.Max(0, clip.StartTime-opts.Padding),
+				End:    clip.EndTime + opts.Padding,
+				Info:   source.clipText(clip, i),
+			}
+			if source.Duration > 0 {
+				segment.End = math.Min(segment.End, source.Duration)
+			}
+
+			if opts.Merge && previous != nil && segment.Start <= previous.End {
+				previous.End = math.Max(previous.End, segment.End)
+				previous.Info += " / " + segment.Info
+				continue
+			}
+			segments = append(segments, segment)
+			previous = &segments[len(segments)-1]
+		}
+	}
+
+	return segments, nil
+}
+
+// unsafeNameChars matches what doesn't belong in a generated file name
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// SafeName turns a title into something usable as a file name
+func SafeName(title string) string {
+	name := strings.Trim(unsafeNameChars.ReplaceAllString(title, "-"), "-.")
+	if name == "" {
+		name = "video"
+	}
+	return name
+}
+
+// shellQuote quotes a string for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// renderFFmpegScript writes a shell script that cuts every segment with
+// ffmpeg, then joins the cuts with the concat demuxer. Cuts are re-encoded so
+// they start on the exact time and share codecs for the join.
+func renderFFmpegScript(title string, segments []Segment) string {
+	name := SafeName(title)
+	output := name + "-highlights.mp4"
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Cuts %d clips of %s and joins them into %s\n", len(segments), strings.Join(strings.Fields(title), " "), output)
+	b.WriteString("set -e\n\n")
+
+	files := make([]string, len(segments))
+	for i, segment := range segments {
+		files[i] = fmt.Sprintf("%s-cut%03d.mp4", name, i+1)
+		fmt.Fprintf(&b, "# %s\n", segment.Info)
+		fmt.Fprintf(&b, "ffmpeg -hide_banner -y -ss %.3f -to %.3f -i %s -c:v libx264 -c:a aac %s\n",
+			segment.Start, segment.End, shellQuote(segment.Source.Path), shellQuote(files[i]))
+	}
+
+	concatList := name + "-concat.txt"
+	fmt.Fprintf(&b, "\ncat > %s <<'EOF'\n", shellQuote(concatList))
+	for _, file := range files {
+		fmt.Fprintf(&b, "file %s\n", shellQuote(file))
+	}
+	b.WriteString("EOF\n\n")
+	fmt.Fprintf(&b, "ffmpeg -hide_banner -y -f concat -safe 0 -i %s -c copy %s\n", shellQuote(concatList), shellQuote(output))
+
+	return b.String()
+}
+
+// toFrames returns the nearest frame of a time in seconds
+func toFrames(seconds float64, fps int) int {
+	return int(math.Round(seconds * float64(fps)))
+}
+
+// timecode formats a frame count as HH:MM:SS:FF at a whole frame rate
+func timecode(frames, fps int) string {
+	return fmt.Sprintf("%02d:%02d:%02d:%02d",
+		frames/(3600*fps), frames/(60*fps)%60, frames/fps%60, frames%fps)
+}
+
+// reelName returns the 8-character reel name of a source for the EDL
+func reelName(source Source) string {
+	name := strings.ToUpper(SafeName(strings.TrimSuffix(filepath.Base(source.Path), filepath.Ext(source.Path))))
+	name = strings.NewReplacer("-", "", ".", "").Replace(name)
+	if len(name) > 8 {
+		name = name[:8]
+	}
+	if name == "" {
+		name = "AX"
+	}
+	return name
+}
+
+// renderEDL writes a CMX3600 edit decision list with one cut per segment,
+// laid end to end on a record timeline starting at 01:00:00:00. Timecodes are
+// non-drop frame at the nearest whole frame rate; the record timeline counts
+// whole frames so each cut starts on the frame the previous one ends.
+func renderEDL(title string, segments []Segment, frameRate float64) string {
+	fps := int(math.Round(frameRate))
+	if fps <= 0 {
+		fps = defaultFrameRate
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "TITLE: %s\n", strings.ToUpper(strings.Join(strings.Fields(title), " ")))
+	b.WriteString("FCM: NON-DROP FRAME\n\n")
+
+	record := 3600 * fps
+	for i, segment := range segments {
+		in, out := toFrames(segment.Start, fps), toFrames(segment.End, fps)
+		length := out - in
+		fmt.Fprintf(&b, "%03d  %-8s AA/V  C        %s %s %s %s\n",
+			i+1, reelName(segment.Source),
+			timecode(in, fps), timecode(out, fps),
+			timecode(record, fps), timecode(record+length, fps))
+		fmt.Fprintf(&b, "* FROM CLIP NAME: %s\n", filepath.Base(segment.Source.Path))
+		fmt.Fprintf(&b, "* COMMENT: %s\n\n", segment.Info)
+		record += length
+	}
+
+	return b.String()
+}
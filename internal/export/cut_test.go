@@ -0,0 +1,250 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+// parseTimecode returns the frame count of an HH:MM:SS:FF timecode
+func parseTimecode(t *testing.T, tc string, fps int) int {
+	t.Helper()
+	var h, m, s, f int
+	if _, err := fmt.Sscanf(tc, "%d:%d:%d:%d", &h, &m, &s, &f); err != nil {
+		t.Fatalf("bad timecode %q: %v", tc, err)
+	}
+	return ((h*60+m)*60+s)*fps + f
+}
+
+// edlEvents returns the source in/out and record in/out frames of the events
+// of an EDL
+func edlEvents(t *testing.T, edl string, fps int) [][4]int {
+	t.Helper()
+	var events [][4]int
+	for _, line := range strings.Split(edl, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 8 || fields[2] != "AA/V" {
+			continue
+		}
+		var event [4]int
+		for i, tc := range fields[4:] {
+			event[i] = parseTimecode(t, tc, fps)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestRenderEDLRecordTimelineHasNoDrift(t *testing.T) {
+	source := Source{Path: "/videos/door.mp4"}
+	var segments []Segment
+	for i := 0; i < 40; i++ {
+		start := float64(i)*1.37 + 0.01
+		segments = append(segments, Segment{Source: source, Start: start, End: start + 0.35, Info: "clip"})
+	}
+
+	events := edlEvents(t, renderEDL("Door", segments, 29.97), 30)
+	if len(events) != len(segments) {
+		t.Fatalf("%d events, want %d", len(events), len(segments))
+	}
+
+	record := 3600 * 30
+	for i, e := range events {
+		if e[2] != record {
+			t.Fatalf("event %d records at frame %d, want %d (right after the previous one)", i+1, e[2], record)
+		}
+		if e[3]-e[2] != e[1]-e[0] {
+			t.Fatalf("event %d records %d frames of a %d frame cut", i+1, e[3]-e[2], e[1]-e[0])
+		}
+		record = e[3]
+	}
+}
+
+func TestSegments(t *testing.T) {
+	door := Source{Path: "door.mp4", Title: "Door", Duration: 11}
+
+	tests := []struct {
+		name    string
+		sources []Source
+		opts    Options
+		want    []Segment
+		wantErr bool
+	}{
+		{
+			name: "out of order clips are sorted",
+			sources: []Source{{Path: "door.mp4", Title: "Door", Clips: []models.VideoClip{
+				clip(10, 12, "B"), clip(2, 4, "A"),
+			}}},
+			want: []Segment{{Start: 2, End: 4, Info: "A"}, {Start: 10, End: 12, Info: "B"}},
+		},
+		{
+			name:    "padding stays inside the video",
+			sources: []Source{withClips(door, clip(0.2, 2, "A"), clip(10, 11, "B"))},
+			opts:    Options{Padding: 1},
+			want:    []Segment{{Start: 0, End: 3, Info: "A"}, {Start: 9, End: 11, Info: "B"}},
+		},
+		{
+			name:    "overlapping clips merge once padded",
+			sources: []Source{withClips(door, clip(0, 2, "A"), clip(2.5, 4, "B"), clip(10, 11, "C"))},
+			opts:    Options{Padding: 0.5, Merge: true},
+			want:    []Segment{{Start: 0, End: 4.5, Info: "A / B"}, {Start: 9.5, End: 11, Info: "C"}},
+		},
+		{
+			name:    "contained clip merges without extending",
+			sources: []Source{withClips(door, clip(1, 8, "A"), clip(2, 3, "B"))},
+			opts:    Options{Merge: true},
+			want:    []Segment{{Start: 1, End: 8, Info: "A / B"}},
+		},
+		{
+			name:    "overlapping clips stay apart without merge",
+			sources: []Source{withClips(door, clip(0, 3, "A"), clip(2, 4, "B"))},
+			want:    []Segment{{Start: 0, End: 3, Info: "A"}, {Start: 2, End: 4, Info: "B"}},
+		},
+		{
+			name: "sources keep their order and never merge together",
+			sources: []Source{
+				{Path: "b.mp4", Title: "B", Clips: []models.VideoClip{clip(5, 6, "B1")}},
+				{Path: "a.mp4", Title: "A", Clips: []models.VideoClip{clip(5, 6, "A1")}},
+			},
+			opts: Options{Merge: true},
+			want: []Segment{{Start: 5, End: 6, Info: "B1"}, {Start: 5, End: 6, Info: "A1"}},
+		},
+		{
+			name:    "zero-length clip",
+			sources: []Source{withClips(door, clip(3, 3, "A"))},
+			wantErr: true,
+		},
+		{
+			name:    "negative padding",
+			sources: []Source{withClips(door, clip(1, 3, "A"))},
+			opts:    Options{Padding: -1},
+			wantErr: true,
+		},
+		{
+			name:    "no path",
+			sources: []Source{{Title: "Door", Clips: []models.VideoClip{clip(1, 3, "A")}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Segments(tt.sources, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Segments() = %+v, want %+v", got, tt.want)
+			}
+			for i, s := range got {
+				w := tt.want[i]
+				if s.Start != w.Start || s.End != w.End || s.Info != w.Info {
+					t.Errorf("segment %d = %.2f-%.2f %q, want %.2f-%.2f %q", i, s.Start, s.End, s.Info, w.Start, w.End, w.Info)
+				}
+			}
+		})
+	}
+}
+
+func withClips(s Source, clips ...models.VideoClip) Source {
+	s.Clips = clips
+	return s
+}
+
+func TestExportFFmpegScript(t *testing.T) {
+	source := Source{Path: "/videos/it's.mp4", Title: "Front door", Clips: []models.VideoClip{
+		clip(10, 12, "B"), clip(2, 4, "A"),
+	}}
+
+	got, n, err := Export(FFmpegScript, []Source{source}, Options{Padding: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `#!/bin/sh
+# Cuts 2 clips of Front door and joins them into Front-door-highlights.mp4
+set -e
+
+# A
+ffmpeg -hide_banner -y -ss 1.000 -to 5.000 -i '/videos/it'\''s.mp4' -c:v libx264 -c:a aac 'Front-door-cut001.mp4'
+# B
+ffmpeg -hide_banner -y -ss 9.000 -to 13.000 -i '/videos/it'\''s.mp4' -c:v libx264 -c:a aac 'Front-door-cut002.mp4'
+
+cat > 'Front-door-concat.txt' <<'EOF'
+file 'Front-door-cut001.mp4'
+file 'Front-door-cut002.mp4'
+EOF
+
+ffmpeg -hide_banner -y -f concat -safe 0 -i 'Front-door-concat.txt' -c copy 'Front-door-highlights.mp4'
+`
+	if n != 2 || got != want {
+		t.Errorf("Export() = %d cuts\n%s\nwant 2 cuts\n%s", n, got, want)
+	}
+}
+
+func TestExportEDL(t *testing.T) {
+	source := Source{Path: "/videos/door cam.mp4", Title: "Door", FPS: 25, Clips: []models.VideoClip{
+		clip(3, 4.4, "B"), clip(1, 2, "A"),
+	}}
+
+	got, n, err := Export(EDL, []Source{source}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "TITLE: DOOR\nFCM: NON-DROP FRAME\n\n" +
+		"001  DOORCAM  AA/V  C        00:00:01:00 00:00:02:00 01:00:00:00 01:00:01:00\n" +
+		"* FROM CLIP NAME: door cam.mp4\n* COMMENT: A\n\n" +
+		"002  DOORCAM  AA/V  C        00:00:03:00 00:00:04:10 01:00:01:00 01:00:02:10\n" +
+		"* FROM CLIP NAME: door cam.mp4\n* COMMENT: B\n\n"
+	if n != 2 || got != want {
+		t.Errorf("Export() = %d cuts\n%s\nwant 2 cuts\n%s", n, got, want)
+	}
+
+	// the frame rate option wins over the video's
+	got, _, err = Export(EDL, []Source{source}, Options{FrameRate: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "00:00:04:20 01:00:01:00 01:00:02:20") {
+		t.Errorf("EDL at 50 fps =\n%s", got)
+	}
+}
+
+func TestExportFFMetadata(t *testing.T) {
+	source := Source{Path: "door.mp4", Title: "Door", Duration: 10, Clips: []models.VideoClip{
+		clip(8, 12, "B"), clip(1, 2, "A"),
+	}}
+
+	got, n, err := Export(FFMetadata, []Source{source}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ";FFMETADATA1\ntitle=Door\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=1000\nEND=2000\ntitle=A\n" +
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=8000\nEND=10000\ntitle=B\n"
+	if n != 2 || got != want {
+		t.Errorf("Export() = %d chapters\n%s\nwant 2 chapters\n%s", n, got, want)
+	}
+
+	if _, _, err := Export(FFMetadata, []Source{source, source}, Options{}); err == nil {
+		t.Error("FFMETADATA of two videos should fail")
+	}
+}
+
+func TestTimecode(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		fps     int
+		want    string
+	}{
+		{0, 30, "00:00:00:00"},
+		{1.5, 30, "00:00:01:15"},
+		{59.99, 30, "00:01:00:00"},
+		{3661.04, 25, "01:01:01:01"},
+	}
+	for _, tt := range tests {
+		if got := timecode(toFrames(tt.seconds, tt.fps), tt.fps); got != tt.want {
+			t.Errorf("timecode(%v s at %d fps) = %s, want %s", tt.seconds, tt.fps, got, tt.want)
+		}
+	}
+}
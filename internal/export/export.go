@@ -0,0 +1,255 @@
+// Package export turns the video clips of a query into files for other
+// tools: subtitles, chapter markers, cut scripts...
+package export
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+// Format identifies an export file format
+type Format string
+
+const (
+	SRT        Format = "srt"
+	WebVTT     Format = "vtt"
+	Chapters   Format = "chapters"
+	FFMetadata Format = "ffmetadata"
+
+	FFmpegScript Format = "ffmpeg"
+	EDL          Format = "edl"
+)
+
+// Formats lists the clip export formats with a short description
+var Formats = []struct {
+	Format      Format
+	Description string
+}{
+	{SRT, "SubRip subtitles (.srt)"},
+	{WebVTT, "WebVTT subtitles (.vtt)"},
+	{Chapters, "YouTube chapters, to paste in a description (.txt)"},
+	{FFMetadata, "FFmpeg metadata chapters (FFMETADATA1)"},
+	{FFmpegScript, "Shell script cutting the clips with ffmpeg and joining them (.sh)"},
+	{EDL, "CMX3600 edit decision list for video editors (.edl)"},
+}
+
+// Extension returns the file extension of a format, with the dot
+func (f Format) Extension() string {
+	switch f {
+	case SRT:
+		return ".srt"
+	case WebVTT:
+		return ".vtt"
+	case FFMetadata:
+		return ".ffmetadata"
+	case FFmpegScript:
+		return ".sh"
+	case EDL:
+		return ".edl"
+	default:
+		return ".txt"
+	}
+}
+
+// IsCut reports whether a format cuts the clips out of the video rather than
+// annotating it
+func (f Format) IsCut() bool {
+	return f == FFmpegScript || f == EDL
+}
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f.Format) == strings.ToLower(name) {
+			return f.Format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown export format %q", name)
+}
+
+// Clips validates the clips of a query against the video duration and
+// returns them sorted by start time. A duration of 0 means unknown and only
+// checks the clips are well-formed. Clips ending past the duration are cut
+// at the end of the video; clips starting past it are an error.
+func Clips(clips []models.VideoClip, duration float64) ([]models.VideoClip, error) {
+	if len(clips) == 0 {
+		return nil, fmt.Errorf("the query has no video clips to export")
+	}
+
+	valid := make([]models.VideoClip, len(clips))
+	for i, clip := range clips {
+		switch {
+		case clip.StartTime < 0:
+			return nil, fmt.Errorf("clip %d starts before the video (%.1fs)", i+1, clip.StartTime)
+		case clip.EndTime <= clip.StartTime:
+			return nil, fmt.Errorf("clip %d ends before it starts (%.1fs - %.1fs)", i+1, clip.StartTime, clip.EndTime)
+		case duration > 0 && clip.StartTime >= duration:
+			return nil, fmt.Errorf("clip %d starts after the end of the video (%.1fs, video is %.1fs)", i+1, clip.StartTime, duration)
+		}
+		if duration > 0 && clip.EndTime > duration {
+			clip.EndTime = duration
+		}
+		valid[i] = clip
+	}
+
+	sort.SliceStable(valid, func(i, j int) bool { return valid[i].StartTime < valid[j].StartTime })
+	return valid, nil
+}
+
+// Render writes the clips of a query in the given format. title names the
+// video in formats that have a title; duration is the length of the video in
+// seconds, 0 when unknown.
+func Render(format Format, title string, clips []models.VideoClip, duration float64) (string, error) {
+	switch format {
+	case SRT:
+		return renderSRT(clips), nil
+	case WebVTT:
+		return renderWebVTT(clips), nil
+	case Chapters:
+		return renderChapters(clips, duration)
+	case FFMetadata:
+		return renderFFMetadata(title, clips), nil
+	default:
+		return "", fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// cueText returns the text of a clip on a single line
+func cueText(clip models.VideoClip, index int) string {
+	text := strings.Join(strings.Fields(clip.Info), " ")
+	if text == "" {
+		text = fmt.Sprintf("Clip %d", index+1)
+	}
+	return text
+}
+
+// splitSeconds splits a time in seconds into hours, minutes, seconds and
+// milliseconds
+func splitSeconds(seconds float64) (int, int, int, int) {
+	ms := int(seconds*1000 + 0.5)
+	return ms / 3600000, ms / 60000 % 60, ms / 1000 % 60, ms % 1000
+}
+
+// timestamp formats seconds as HH:MM:SS followed by sep and milliseconds
+func timestamp(seconds float64, sep string) string {
+	h, m, s, ms := splitSeconds(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", h, m, s, sep, ms)
+}
+
+// renderSRT writes SubRip subtitles
+func renderSRT(clips []models.VideoClip) string {
+	var b strings.Builder
+	for i, clip := range clips {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(clip.StartTime, ","), timestamp(clip.EndTime, ","), cueText(clip, i))
+	}
+	return b.String()
+}
+
+// escapeWebVTT escapes the characters that start markup in WebVTT cue text
+func escapeWebVTT(s string) string {
+	replacer := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	return replacer.Replace(s)
+}
+
+// renderWebVTT writes WebVTT subtitles
+func renderWebVTT(clips []models.VideoClip) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for i, clip := range clips {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(clip.StartTime, "."), timestamp(clip.EndTime, "."), escapeWebVTT(cueText(clip, i)))
+	}
+	return b.String()
+}
+
+// chapterTimestamp formats seconds the way YouTube expects: M:SS, or H:MM:SS
+// past an hour
+func chapterTimestamp(seconds float64) string {
+	h, m, s, _ := splitSeconds(seconds)
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
+
+// YouTube only shows the chapters of a description with at least
+// minChapters chapters of minChapterLength seconds or more
+const (
+	minChapters      = 3
+	minChapterLength = 10
+)
+
+// chapter is a YouTube chapter starting at a whole second
+type chapter struct {
+	start int
+	title string
+}
+
+// renderChapters writes YouTube chapter lines. The first chapter starts at
+// 00:00: a clip starting in the first seconds is moved there, otherwise a
+// Start chapter is added. A clip starting too soon after the previous
+// chapter joins it, as does a last chapter too close to the end of the video
+// (the end of the clips when the duration is unknown).
+func renderChapters(clips []models.VideoClip, duration float64) (string, error) {
+	end := duration
+	if end <= 0 {
+		for _, clip := range clips {
+			end = math.Max(end, clip.EndTime)
+		}
+	}
+
+	var chapters []chapter
+	for i, clip := range clips {
+		current := chapter{start: int(clip.StartTime + 0.5), title: cueText(clip, i)}
+		switch {
+		case len(chapters) == 0 && current.start < minChapterLength:
+			current.start = 0
+		case len(chapters) == 0:
+			chapters = append(chapters, chapter{start: 0, title: "Start"})
+		case current.start-chapters[len(chapters)-1].start < minChapterLength:
+			chapters[len(chapters)-1].title += " / " + current.title
+			continue
+		}
+		chapters = append(chapters, current)
+	}
+	for n := len(chapters); n > 1 && float64(chapters[n-1].start)+minChapterLength > end; n-- {
+		chapters[n-2].title += " / " + chapters[n-1].title
+		chapters = chapters[:n-1]
+	}
+
+	if len(chapters) < minChapters {
+		return "", fmt.Errorf("YouTube needs at least %d chapters of %ds or more, the clips make %d", minChapters, minChapterLength, len(chapters))
+	}
+
+	var b strings.Builder
+	for _, c := range chapters {
+		fmt.Fprintf(&b, "%s %s\n", chapterTimestamp(float64(c.start)), c.title)
+	}
+	return b.String(), nil
+}
+
+// escapeFFMetadata escapes the characters FFmpeg treats specially in
+// metadata values
+func escapeFFMetadata(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", `\`+"\n")
+	return replacer.Replace(s)
+}
+
+// renderFFMetadata writes an FFMETADATA1 file with one chapter per clip
+func renderFFMetadata(title string, clips []models.VideoClip) string {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	if title != "" {
+		fmt.Fprintf(&b, "title=%s\n", escapeFFMetadata(title))
+	}
+	for i, clip := range clips {
+		b.WriteString("\n[CHAPTER]\nTIMEBASE=1/1000\n")
+		fmt.Fprintf(&b, "START=%d\n", int(clip.StartTime*1000+0.5))
+		fmt.Fprintf(&b, "END=%d\n", int(clip.EndTime*1000+0.5))
+		fmt.Fprintf(&b, "title=%s\n", escapeFFMetadata(cueText(clip, i)))
+	}
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+func clip(start, end float64, info string) models.VideoClip {
+	return models.VideoClip{StartTime: start, EndTime: end, Info: info}
+}
+
+func TestRender(t *testing.T) {
+	clips := []models.VideoClip{
+		clip(1.5, 3.25, "Cats &\n <dogs>"),
+		clip(3661.001, 3662, ""),
+	}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{SRT, "1\n00:00:01,500 --> 00:00:03,250\nCats & <dogs>\n\n" +
+			"2\n01:01:01,001 --> 01:01:02,000\nClip 2\n\n"},
+		{WebVTT, "WEBVTT\n\n" +
+			"1\n00:00:01.500 --> 00:00:03.250\nCats &amp; &lt;dogs&gt;\n\n" +
+			"2\n01:01:01.001 --> 01:01:02.000\nClip 2\n\n"},
+		{FFMetadata, ";FFMETADATA1\ntitle=Front\\=door\\;2\n" +
+			"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=1500\nEND=3250\ntitle=Cats & <dogs>\n" +
+			"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=3661001\nEND=3662000\ntitle=Clip 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			got, err := Render(tt.format, "Front=door;2", clips, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Render(%s) =\n%q\nwant\n%q", tt.format, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Render(Format("nope"), "", clips, 0); err == nil {
+		t.Error("Render(unknown format) should fail")
+	}
+}
+
+func TestRenderChapters(t *testing.T) {
+	tests := []struct {
+		name     string
+		clips    []models.VideoClip
+		duration float64
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "one chapter per clip",
+			clips:    []models.VideoClip{clip(0, 5, "A"), clip(30, 35, "B"), clip(90, 95, "C")},
+			duration: 120,
+			want:     "00:00 A\n00:30 B\n01:30 C\n",
+		},
+		{
+			name:     "first clip moved to the start",
+			clips:    []models.VideoClip{clip(5, 8, "A"), clip(30, 35, "B"), clip(90, 95, "C")},
+			duration: 120,
+			want:     "00:00 A\n00:30 B\n01:30 C\n",
+		},
+		{
+			name:     "start chapter added",
+			clips:    []models.VideoClip{clip(20, 25, "A"), clip(60, 65, "B")},
+			duration: 120,
+			want:     "00:00 Start\n00:20 A\n01:00 B\n",
+		},
+		{
+			name:     "clips too close together join",
+			clips:    []models.VideoClip{clip(0, 5, "A"), clip(30, 35, "B"), clip(35, 40, "C"), clip(90, 95, "D")},
+			duration: 120,
+			want:     "00:00 A\n00:30 B / C\n01:30 D\n",
+		},
+		{
+			name:     "last chapter too close to the end joins",
+			clips:    []models.VideoClip{clip(0, 5, "A"), clip(30, 35, "B"), clip(60, 65, "C"), clip(115, 120, "D")},
+			duration: 120,
+			want:     "00:00 A\n00:30 B\n01:00 C / D\n",
+		},
+		{
+			name:     "unknown duration ends with the clips",
+			clips:    []models.VideoClip{clip(0, 5, "A"), clip(30, 35, "B"), clip(60, 75, "C")},
+			duration: 0,
+			want:     "00:00 A\n00:30 B\n01:00 C\n",
+		},
+		{
+			name:     "past an hour",
+			clips:    []models.VideoClip{clip(0, 5, "A"), clip(1800, 1805, "B"), clip(3700, 3705, "C")},
+			duration: 4000,
+			want:     "00:00 A\n30:00 B\n1:01:40 C\n",
+		},
+		{
+			name:     "too few chapters",
+			clips:    []models.VideoClip{clip(0, 5, "A"), clip(30, 35, "B")},
+			duration: 120,
+			wantErr:  true,
+		},
+		{
+			name:     "too few once joined",
+			clips:    []models.VideoClip{clip(0, 5, "A"), clip(30, 35, "B"), clip(60, 65, "C")},
+			duration: 0,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(Chapters, "", tt.clips, tt.duration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("chapters =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClips(t *testing.T) {
+	got, err := Clips([]models.VideoClip{clip(50, 70, "late"), clip(10, 20, "early")}, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Info != "early" || got[1].Info != "late" || got[1].EndTime != 60 {
+		t.Errorf("Clips() = %+v, want sorted and cut at the end of the video", got)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		clips []models.VideoClip
+	}{
+		{"none", nil},
+		{"negative start", []models.VideoClip{clip(-1, 5, "")}},
+		{"zero length", []models.VideoClip{clip(5, 5, "")}},
+		{"ends before it starts", []models.VideoClip{clip(5, 4, "")}},
+		{"starts after the video", []models.VideoClip{clip(60, 65, "")}},
+	} {
+		if _, err := Clips(tt.clips, 60); err == nil {
+			t.Errorf("Clips(%s) should fail", tt.name)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats {
+		got, err := ParseFormat(strings.ToUpper(string(f.Format)))
+		if err != nil || got != f.Format {
+			t.Errorf("ParseFormat(%q) = %q, %v", f.Format, got, err)
+		}
+	}
+	if _, err := ParseFormat("mp4"); err == nil {
+		t.Error("ParseFormat(mp4) should fail")
+	}
+}
+
+func TestExportWallClockIsOptIn(t *testing.T) {
+	source := Source{
+		Path:  "cam.mp4",
+		Title: "Cam",
+		Start: time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC),
+		Clips: []models.VideoClip{clip(60, 90, "Delivery")},
+	}
+
+	got, _, err := Export(SRT, []Source{source}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "\nDelivery\n") {
+		t.Errorf("SRT without WallClock =\n%s", got)
+	}
+
+	got, _, err = Export(SRT, []Source{source}, Options{WallClock: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "\n09:01:00-09:01:30 Delivery\n") {
+		t.Errorf("SRT with WallClock =\n%s", got)
+	}
+
+	// cut comments always have it
+	got, _, err = Export(EDL, []Source{source}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "* COMMENT: 09:01:00-09:01:30 Delivery\n") {
+		t.Errorf("EDL =\n%s", got)
+	}
+}
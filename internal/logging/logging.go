@@ -0,0 +1,228 @@
+// Package logging sets up the structured log of the application: log/slog
+// records written to a rotating file and kept in memory for the TUI log
+// viewer. Every record goes through a handler that redacts API keys, so
+// nothing logged can leak one.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Levels of the log.level setting, from the most to the least verbose
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+	LevelOff   = "off"
+)
+
+// levels maps the log.level setting to slog levels
+var levels = map[string]slog.Level{
+	LevelDebug: slog.LevelDebug,
+	LevelInfo:  slog.LevelInfo,
+	LevelWarn:  slog.LevelWarn,
+	LevelError: slog.LevelError,
+}
+
+// ValidLevel reports whether level is a value of the log.level setting
+func ValidLevel(level string) bool {
+	_, ok := levels[level]
+	return ok || level == LevelOff
+}
+
+// recentLines is how many log lines are kept for the log viewer
+const recentLines = 500
+
+// previewBytes is how much of a body is logged
+const previewBytes = 512
+
+// redacted replaces secrets in log records
+const redacted = "[REDACTED]"
+
+// Options configure the log
+type Options struct {
+	Level    string // one of the Level constants
+	Path     string // log file
+	MaxSize  int64  // bytes written to the file before it is rotated
+	MaxFiles int    // rotated files kept next to it
+}
+
+var (
+	mu      sync.Mutex
+	secrets = make(map[string]bool)
+	path    string
+	recent  = &ring{max: recentLines}
+)
+
+// Setup makes the default slog logger write to the log file and the recent
+// lines. The file is only created once something is logged.
+func Setup(opts Options) error {
+	if !ValidLevel(opts.Level) {
+		return fmt.Errorf("unknown log level %q", opts.Level)
+	}
+
+	var out io.Writer = io.Discard
+	level := slog.LevelError + 1
+	if opts.Level != LevelOff {
+		level = levels[opts.Level]
+		out = io.MultiWriter(&rotatingFile{path: opts.Path, maxSize: opts.MaxSize, maxFiles: opts.MaxFiles}, recent)
+	}
+
+	mu.Lock()
+	path = opts.Path
+	if opts.Level == LevelOff {
+		path = ""
+	}
+	mu.Unlock()
+
+	handler := slog.NewTextHandler(out, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(&redactHandler{next: handler}))
+	return nil
+}
+
+// Path returns the log file, empty when logging is off
+func Path() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return path
+}
+
+// Recent returns the last lines logged, oldest first
+func Recent() []string {
+	return recent.lines()
+}
+
+// AddSecret makes the log redact s wherever it appears, e.g. an API key
+func AddSecret(s string) {
+	if s == "" {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	secrets[s] = true
+}
+
+// Redact replaces the secrets in s
+func Redact(s string) string {
+	mu.Lock()
+	defer mu.Unlock()
+	for secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// Preview returns the start of a body for the log. Secrets are redacted
+// from the whole body first, so one can't leak by straddling the cut, and the
+// cut never splits a UTF-8 character.
+func Preview(body []byte) string {
+	s := Redact(string(body))
+	if len(s) <= previewBytes {
+		return s
+	}
+	cut := previewBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s… (%d bytes)", s[:cut], len(body))
+}
+
+// sensitiveKeys are attributes whose value is never logged
+var sensitiveKeys = map[string]bool{
+	"x-api-key": true,
+	"api_key":   true,
+	"apikey":    true,
+}
+
+// redactHandler redacts the secrets of records before passing them on
+type redactHandler struct {
+	next slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	clean := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		clean.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, clean)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = redactAttr(a)
+	}
+	return &redactHandler{next: h.next.WithAttrs(clean)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name)}
+}
+
+// redactAttr redacts the secrets in an attribute, and the whole value of
+// an attribute naming a key
+func redactAttr(a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindAny:
+		// Errors and other values are logged as text, which may hold a key
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		clean := make([]any, len(group))
+		for i, ga := range group {
+			clean[i] = redactAttr(ga)
+		}
+		return slog.Group(a.Key, clean...)
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+// ring keeps the last lines written to it
+type ring struct {
+	mu    sync.Mutex
+	max   int
+	buf   []string
+	first int // index of the oldest line once buf is full
+}
+
+func (r *ring) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if len(r.buf) < r.max {
+			r.buf = append(r.buf, line)
+			continue
+		}
+		r.buf[r.first] = line
+		r.first = (r.first + 1) % r.max
+	}
+	return len(p), nil
+}
+
+// lines returns the lines, oldest first
+func (r *ring) lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines := make([]string, 0, len(r.buf))
+	lines = append(lines, r.buf[r.first:]...)
+	return append(lines, r.buf[:r.first]...)
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPreviewRedactsBeforeCutting(t *testing.T) {
+	AddSecret("straddling-secret-key")
+
+	// the key starts just before the cut
+	body := strings.Repeat("a", previewBytes-5) + "straddling-secret-key" + strings.Repeat("b", 100)
+	got := Preview([]byte(body))
+	if strings.Contains(got, "strad") {
+		t.Errorf("Preview() = %q, leaks the start of the key", got)
+	}
+	if !strings.HasSuffix(got, fmt.Sprintf("… (%d bytes)", len(body))) {
+		t.Errorf("Preview() = %q, want the full size", got)
+	}
+}
+
+func TestPreviewKeepsUTF8Whole(t *testing.T) {
+	short := "é short body"
+	if got := Preview([]byte(short)); got != short {
+		t.Errorf("Preview(%q) = %q", short, got)
+	}
+
+	// é is two bytes: the cut falls in the middle of one
+	body := "a" + strings.Repeat("é", previewBytes)
+	got := Preview([]byte(body))
+	if !utf8.ValidString(got) {
+		t.Fatalf("Preview() = %q, splits a character", got)
+	}
+	kept, _, _ := strings.Cut(got, "…")
+	if len(kept) != previewBytes-1 {
+		t.Errorf("Preview() kept %d bytes, want %d", len(kept), previewBytes-1)
+	}
+}
+
+func TestRedactHandler(t *testing.T) {
+	AddSecret("handler-secret-key")
+	AddSecret("")
+
+	var out bytes.Buffer
+	logger := slog.New(&redactHandler{next: slog.NewTextHandler(&out, nil)})
+	logger.With("base", "handler-secret-key").Info("using handler-secret-key",
+		"x-api-key", "anything",
+		"err", errors.New("bad key handler-secret-key"),
+		slog.Group("request", "api_key", "anything", "url", "https://api/?k=handler-secret-key"),
+		"count", 3,
+	)
+
+	line := out.String()
+	if strings.Contains(line, "handler-secret-key") || strings.Contains(line, "anything") {
+		t.Errorf("the log leaks a secret:\n%s", line)
+	}
+	for _, want := range []string{`msg="using [REDACTED]"`, "x-api-key=[REDACTED]", "request.api_key=[REDACTED]", "count=3"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("the log should have %s:\n%s", want, line)
+		}
+	}
+}
+
+func TestRing(t *testing.T) {
+	r := &ring{max: 3}
+	r.Write([]byte("one\ntwo\n"))
+	r.Write([]byte("three\nfour\n"))
+	r.Write([]byte("five\n"))
+
+	got := strings.Join(r.lines(), ",")
+	if got != "three,four,five" {
+		t.Errorf("lines = %s, want three,four,five", got)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	f := &rotatingFile{path: path, maxSize: 10, maxFiles: 2}
+	defer func() { f.file.Close() }()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(name)
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q, %v, want %q", filepath.Base(name), data, err, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("only 2 rotated files should be kept")
+	}
+}
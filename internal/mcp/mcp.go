@@ -0,0 +1,269 @@
+// Package mcp implements a Model Context Protocol server over stdio, letting
+// AI assistants list, upload and ask questions about videos. Messages are
+// newline-delimited JSON-RPC 2.0.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/db"
+	"github.com/fboucher/be-my-eyes/internal/version"
+)
+
+// protocolVersion is the MCP revision implemented by this server
+const protocolVersion = "2024-11-05"
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// request is an incoming JSON-RPC request or notification (no ID)
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is an outgoing JSON-RPC response
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error object
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Server answers MCP requests with the API client and the history database
+type Server struct {
+	apiClient   *api.Client
+	database    *db.DB
+	location    *time.Location // time zone of wall-clock history searches
+	cacheTTL    time.Duration  // how long answers are reused, 0 to always ask the API
+	uploadIndex bool           // index uploads that don't say otherwise
+
+	mu  sync.Mutex // serializes writes to out
+	out io.Writer
+
+	pendingMu sync.Mutex
+	pending   map[string]context.CancelFunc // cancels running requests by ID
+}
+
+// New creates an MCP server. loc is the time zone of wall-clock history
+// searches, cacheTTL how long answers are reused and uploadIndex whether
+// uploads that don't say otherwise are indexed.
+func New(apiClient *api.Client, database *db.DB, loc *time.Location, cacheTTL time.Duration, uploadIndex bool) *Server {
+	return &Server{
+		apiClient:   apiClient,
+		database:    database,
+		location:    loc,
+		cacheTTL:    cacheTTL,
+		uploadIndex: uploadIndex,
+		pending:     make(map[string]context.CancelFunc),
+	}
+}
+
+// Serve reads requests from in and writes responses to out until in is
+// closed or ctx is cancelled. Requests are handled concurrently so a slow
+// question doesn't block the others, and notifications/cancelled stops the
+// request it names.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.out = out
+
+	// the reader may stay blocked on in after ctx is cancelled; it exits
+	// with the process
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		var line []byte
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			if err != nil {
+				return fmt.Errorf("failed to read request: %w", err)
+			}
+			return nil
+		case line = <-lines:
+		}
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.write(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: "parse error"}})
+			continue
+		}
+
+		// the request is registered before the next line is read, so a
+		// cancellation that follows it always finds it
+		reqCtx, cancel := context.WithCancel(ctx)
+		if len(req.ID) > 0 {
+			s.track(req.ID, cancel)
+		}
+
+		wg.Add(1)
+		go func(req request) {
+			defer wg.Done()
+			defer cancel()
+			if len(req.ID) > 0 {
+				defer s.untrack(req.ID)
+			}
+			s.handle(ctx, reqCtx, req)
+		}(req)
+	}
+}
+
+// requestKey identifies a request ID, whatever its JSON spacing
+func requestKey(id json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, id); err != nil {
+		return string(id)
+	}
+	return buf.String()
+}
+
+// track records the cancel func of a running request
+func (s *Server) track(id json.RawMessage, cancel context.CancelFunc) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	s.pending[requestKey(id)] = cancel
+}
+
+// untrack forgets a finished request
+func (s *Server) untrack(id json.RawMessage) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	delete(s.pending, requestKey(id))
+}
+
+// cancelRequest stops a running request, as asked by notifications/cancelled.
+// Unknown or finished requests are ignored.
+func (s *Server) cancelRequest(params json.RawMessage) {
+	var p struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || len(p.RequestID) == 0 {
+		return
+	}
+
+	s.pendingMu.Lock()
+	cancel, ok := s.pending[requestKey(p.RequestID)]
+	s.pendingMu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// handle dispatches a request and writes its response. Notifications and
+// requests cancelled by the client get no response; reqCtx is ctx with the
+// cancellation of the request.
+func (s *Server) handle(ctx, reqCtx context.Context, req request) {
+	result, rpcErr := s.dispatch(reqCtx, req)
+	if len(req.ID) == 0 || (reqCtx.Err() != nil && ctx.Err() == nil) {
+		return
+	}
+
+	resp := response{JSONRPC: "2.0", ID: req.ID}
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	s.write(resp)
+}
+
+// dispatch runs the method of a request
+func (s *Server) dispatch(ctx context.Context, req request) (interface{}, *rpcError) {
+	if req.JSONRPC != "2.0" {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "jsonrpc must be 2.0"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		return s.initialize(), nil
+	case "notifications/initialized":
+		return nil, nil
+	case "notifications/cancelled":
+		s.cancelRequest(req.Params)
+		return nil, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid tools/call params"}
+		}
+		return s.callTool(ctx, params.Name, params.Arguments)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+// initialize answers the handshake with the protocol version and capabilities
+// of the server
+func (s *Server) initialize() interface{} {
+	return map[string]interface{}{
+		"protocolVersion": protocolVersion,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{},
+		},
+		"serverInfo": map[string]string{
+			"name":    "be-my-eyes",
+			"version": version.Version,
+		},
+	}
+}
+
+// write sends one response line
+func (s *Server) write(resp response) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{Code: codeInvalidRequest, Message: err.Error()}})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.out.Write(append(data, '\n'))
+}
@@ -0,0 +1,147 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/db"
+)
+
+// session runs a server over pipes against a fake Reka API whose answers
+// wait for the request to be cancelled
+type session struct {
+	in      *io.PipeWriter
+	out     *bufio.Scanner
+	done    chan error
+	started chan struct{} // a question reached the fake API
+}
+
+func newSession(t *testing.T, ctx context.Context) *session {
+	t.Helper()
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	reka := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/videos/get":
+			io.WriteString(w, `{"results":[{"video_id":"v1","metadata":{"title":"Door"}}]}`)
+		case "/qa/chat":
+			started <- struct{}{}
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+		}
+	}))
+	t.Cleanup(reka.Close)
+	t.Cleanup(func() { close(release) })
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	t.Cleanup(func() { inW.Close(); outR.Close() })
+
+	s := New(api.NewClientWithOptions("key", reka.URL, time.Minute), database, time.UTC, 0, true)
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, inR, outW) }()
+
+	return &session{in: inW, out: bufio.NewScanner(outR), done: done, started: started}
+}
+
+func (s *session) send(t *testing.T, line string) {
+	t.Helper()
+	if _, err := io.WriteString(s.in, line+"\n"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (s *session) receive(t *testing.T) response {
+	t.Helper()
+	if !s.out.Scan() {
+		t.Fatalf("no response: %v", s.out.Err())
+	}
+	var resp response
+	if err := json.Unmarshal(s.out.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestServeAnswersRequests(t *testing.T) {
+	s := newSession(t, context.Background())
+
+	s.send(t, `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	if resp := s.receive(t); string(resp.ID) != "1" || resp.Error != nil {
+		t.Fatalf("initialize = %+v", resp)
+	}
+
+	s.send(t, `not json`)
+	if resp := s.receive(t); resp.Error == nil || resp.Error.Code != codeParseError {
+		t.Fatalf("parse error = %+v", resp)
+	}
+
+	s.send(t, `{"jsonrpc":"2.0","id":2,"method":"nope"}`)
+	if resp := s.receive(t); resp.Error == nil || resp.Error.Code != codeMethodNotFound {
+		t.Fatalf("unknown method = %+v", resp)
+	}
+
+	s.in.Close()
+	if err := <-s.done; err != nil {
+		t.Errorf("Serve() = %v, want nil at end of input", err)
+	}
+}
+
+func TestServeStopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newSession(t, ctx)
+
+	// input stays open: Serve must not wait for another line
+	cancel()
+	select {
+	case err := <-s.done:
+		if err != context.Canceled {
+			t.Errorf("Serve() = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() still blocked reading input after cancel")
+	}
+}
+
+func TestNotificationCancelsRequest(t *testing.T) {
+	s := newSession(t, context.Background())
+
+	s.send(t, `{"jsonrpc":"2.0","id":"ask-1","method":"tools/call","params":{"name":"ask_video","arguments":{"video_id":"v1","question":"Who?"}}}`)
+	select {
+	case <-s.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("question never reached the API")
+	}
+
+	s.send(t, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId": "ask-1","reason":"user"}}`)
+	s.send(t, `{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+
+	if resp := s.receive(t); string(resp.ID) != "3" {
+		t.Fatalf("response = %+v, want the ping", resp)
+	}
+
+	// Serve waits for the question, which only returns once cancelled, and
+	// would block writing a response nobody reads
+	s.in.Close()
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled request still running or answered")
+	}
+}
@@ -0,0 +1,231 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fboucher/be-my-eyes/internal/logging"
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/qa"
+)
+
+// tool describes an MCP tool for tools/list
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// objectSchema builds a JSON schema for an object with string properties
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// stringProperty builds a JSON schema string property
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+// tools are the tools offered to MCP clients
+var tools = []tool{
+	{
+		Name:        "list_videos",
+		Description: "List the videos of the Reka Vision library with their ID, title, duration and indexing status.",
+		InputSchema: objectSchema(map[string]interface{}{}),
+	},
+	{
+		Name:        "get_video",
+		Description: "Get the details and metadata of one video.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"video_id": stringProperty("ID of the video"),
+		}, "video_id"),
+	},
+	{
+		Name:        "ask_video",
+		Description: "Ask a question about the content of an indexed video. The answer may include clips with start and end times in seconds. The answer is saved to the local history.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"video_id": stringProperty("ID of the video"),
+			"question": stringProperty("Question about the video"),
+		}, "video_id", "question"),
+	},
+	{
+		Name:        "upload_video_url",
+		Description: "Add a video to the library from a public URL. Indexing takes a while; check its status with get_video.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"video_name": stringProperty("Name of the video"),
+			"video_url":  stringProperty("Public URL of the video file"),
+			"index": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Index the video so questions can be asked (defaults to the defaults.upload_index setting)",
+			},
+		}, "video_name", "video_url"),
+	},
+	{
+		Name:        "search_history",
+		Description: "Search past questions and answers. Both arguments are optional; without them the whole history is returned.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"query":    stringProperty("Text to find in questions or answers, or a time-of-day range such as \"between 14:00 and 14:30\" matching the wall-clock times of the clips"),
+			"video_id": stringProperty("Only return the history of this video"),
+		}),
+	},
+}
+
+// toolResult is the result of tools/call
+type toolResult struct {
+	Content []toolContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+// toolContent is a text block of a tool result
+type toolContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// callTool runs a tool. Tool failures are reported in the result, as MCP
+// expects, so the model can see them; only unknown tools and bad arguments
+// are protocol errors.
+func (s *Server) callTool(ctx context.Context, name string, arguments json.RawMessage) (interface{}, *rpcError) {
+	var args struct {
+		VideoID   string `json:"video_id"`
+		Question  string `json:"question"`
+		VideoName string `json:"video_name"`
+		VideoURL  string `json:"video_url"`
+		Index     *bool  `json:"index"`
+		Query     string `json:"query"`
+	}
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid arguments: " + err.Error()}
+		}
+	}
+
+	var result interface{}
+	var err error
+
+	switch name {
+	case "list_videos":
+		result, err = s.listVideos()
+	case "get_video":
+		if args.VideoID == "" {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "video_id is required"}
+		}
+		result, err = s.getVideo(args.VideoID)
+	case "ask_video":
+		if args.VideoID == "" || args.Question == "" {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "video_id and question are required"}
+		}
+		result, err = s.askVideo(ctx, args.VideoID, args.Question)
+	case "upload_video_url":
+		if args.VideoName == "" || args.VideoURL == "" {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "video_name and video_url are required"}
+		}
+		index := s.uploadIndex
+		if args.Index != nil {
+			index = *args.Index
+		}
+		result, err = s.uploadVideo(args.VideoName, args.VideoURL, index)
+	case "search_history":
+		result, err = s.searchHistory(args.Query, args.VideoID)
+	default:
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + name}
+	}
+
+	if err != nil {
+		return toolResult{
+			Content: []toolContent{{Type: "text", Text: logging.Redact(err.Error())}},
+			IsError: true,
+		}, nil
+	}
+
+	text, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: err.Error()}
+	}
+	return toolResult{Content: []toolContent{{Type: "text", Text: string(text)}}}, nil
+}
+
+// videoSummary is the compact form of a video returned by list_videos
+type videoSummary struct {
+	VideoID        string  `json:"video_id"`
+	Title          string  `json:"title"`
+	Duration       float64 `json:"duration"`
+	IndexingStatus string  `json:"indexing_status"`
+}
+
+// listVideos returns a summary of every video of the library
+func (s *Server) listVideos() (interface{}, error) {
+	response, err := s.apiClient.GetAllVideos()
+	if err != nil {
+		return nil, err
+	}
+
+	videos := make([]videoSummary, len(response.Results))
+	for i, v := range response.Results {
+		videos[i] = videoSummary{
+			VideoID:        v.VideoID,
+			Title:          qa.VideoTitle(v),
+			Duration:       v.Metadata.Duration,
+			IndexingStatus: v.IndexingStatus,
+		}
+	}
+	return videos, nil
+}
+
+// getVideo returns one video
+func (s *Server) getVideo(videoID string) (*models.Video, error) {
+	response, err := s.apiClient.GetVideos([]string{videoID})
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Results) == 0 {
+		return nil, fmt.Errorf("video %s not found", videoID)
+	}
+	return &response.Results[0], nil
+}
+
+// askVideo asks a question and records the answer in the history
+func (s *Server) askVideo(ctx context.Context, videoID, question string) (interface{}, error) {
+	video := models.Video{VideoID: videoID}
+	if v, err := s.getVideo(videoID); err == nil {
+		video = *v
+	}
+
+	query, err := qa.AskVideo(ctx, s.apiClient, s.database, video, question, qa.Options{CacheTTL: s.cacheTTL})
+	if err != nil {
+		return nil, err
+	}
+	if query.Error != nil && *query.Error != "" {
+		return nil, fmt.Errorf("%s", *query.Error)
+	}
+	return query, nil
+}
+
+// uploadVideo adds a video to the library from a URL
+func (s *Server) uploadVideo(name, url string, index bool) (interface{}, error) {
+	body, err := s.apiClient.UploadVideo(name, url, index)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(body), nil
+}
+
+// searchHistory returns past questions and answers
+func (s *Server) searchHistory(query, videoID string) (interface{}, error) {
+	history, err := qa.SearchHistory(s.apiClient, s.database, query, videoID, s.location)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []models.QueryHistory{}
+	}
+	return history, nil
+}
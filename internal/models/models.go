@@ -36,6 +36,7 @@ type QueryHistory struct {
 	Status     string      `json:"status"`
 	CreatedAt  time.Time   `json:"created_at"`
 	VideoClips []VideoClip `json:"video_clips"`
+	Cached     bool        `json:"cached,omitempty"` // served from the answer cache, not asked again
 }
 
 // VideoClip represents a video clip with timing information
@@ -48,6 +49,15 @@ type VideoClip struct {
 	Info      string  `json:"info"`
 }
 
+// PromptTemplate represents a reusable question. Text is a Go text/template
+// rendered with the metadata of the video being asked about.
+type PromptTemplate struct {
+	Name      string    `json:"name"`
+	Text      string    `json:"text"`
+	BuiltIn   bool      `json:"built_in"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // ChatMessage represents a message in the chat API request
 type ChatMessage struct {
 	Role    string `json:"role"`
@@ -80,3 +90,41 @@ type VideosGetRequest struct {
 type VideosGetResponse struct {
 	Results []Video `json:"results"`
 }
+
+// SearchRequest represents the request body for the search API
+type SearchRequest struct {
+	Query      string   `json:"query"`
+	MaxResults int      `json:"max_results,omitempty"`
+	VideoIDs   []string `json:"video_ids,omitempty"`
+}
+
+// SearchResult is a video segment matching a search, best matches first
+type SearchResult struct {
+	VideoID   string  `json:"video_id"`
+	StartTime float64 `json:"start_timestamp"`
+	EndTime   float64 `json:"end_timestamp"`
+	Score     float64 `json:"score"`
+	Caption   string  `json:"plain_text_caption"`
+}
+
+// SearchResponse represents the response from the search API
+type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+}
+
+// SearchHistory represents a saved search and the segments it found
+type SearchHistory struct {
+	ID        int            `json:"id"`
+	Query     string         `json:"query"`
+	CreatedAt time.Time      `json:"created_at"`
+	Results   []SearchResult `json:"results"`
+}
+
+// AnswerCacheEntry points a cached question to the saved query answering it.
+// Fingerprint identifies the state of the video when it was answered.
+type AnswerCacheEntry struct {
+	Key         string    `json:"key"`
+	QueryID     int       `json:"query_id"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at"`
+}
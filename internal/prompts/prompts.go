@@ -0,0 +1,103 @@
+// Package prompts manages the prompt library: reusable question templates
+// rendered with the metadata of a video.
+package prompts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/fboucher/be-my-eyes/internal/db"
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+// builtins are always available; a saved template with the same name
+// replaces them
+var builtins = []models.PromptTemplate{
+	{Name: "summarize", Text: "Summarize this video ({{.Title}}, {{.Duration}}s)."},
+	{Name: "on-screen-text", Text: "List every on-screen text in this video with the time it appears."},
+	{Name: "scenes", Text: "Describe each scene of this video with timestamps."},
+}
+
+// Data is what a template can use: every field of models.VideoMetadata
+// ({{.Title}}, {{.Duration}}, {{.Description}}...) plus {{.VideoID}}
+type Data struct {
+	models.VideoMetadata
+	VideoID string
+}
+
+// List returns the built-in and saved templates ordered by name
+func List(database *db.DB) ([]models.PromptTemplate, error) {
+	saved, err := database.GetPromptTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]models.PromptTemplate)
+	for _, t := range builtins {
+		t.BuiltIn = true
+		byName[t.Name] = t
+	}
+	for _, t := range saved {
+		byName[t.Name] = t
+	}
+
+	templates := make([]models.PromptTemplate, 0, len(byName))
+	for _, t := range byName {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+
+	return templates, nil
+}
+
+// Get returns the template with the given name
+func Get(database *db.DB, name string) (*models.PromptTemplate, error) {
+	templates, err := List(database)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		if t.Name == name {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("prompt template %q not found", name)
+}
+
+// Save validates and stores a template
+func Save(database *db.DB, name, text string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("invalid template name %q: use a single word", name)
+	}
+	if _, err := template.New(name).Parse(text); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	return database.SavePromptTemplate(name, text)
+}
+
+// IsTemplate reports whether a question uses template actions
+func IsTemplate(text string) bool {
+	return strings.Contains(text, "{{")
+}
+
+// Render executes a template with the metadata of a video
+func Render(t models.PromptTemplate, video models.Video) (string, error) {
+	tmpl, err := template.New(t.Name).Option("missingkey=error").Parse(t.Text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %q: %w", t.Name, err)
+	}
+
+	var b strings.Builder
+	data := Data{VideoMetadata: video.Metadata, VideoID: video.VideoID}
+	if data.Title == "" {
+		data.Title = video.VideoID
+	}
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render template %q: %w", t.Name, err)
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
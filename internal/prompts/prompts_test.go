@@ -0,0 +1,104 @@
+package prompts
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fboucher/be-my-eyes/internal/db"
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+func openDB(t *testing.T) *db.DB {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestRender(t *testing.T) {
+	video := models.Video{VideoID: "v1", Metadata: models.VideoMetadata{Title: "Door", Duration: 12.5}}
+
+	tests := []struct {
+		name    string
+		text    string
+		video   models.Video
+		want    string
+		wantErr bool
+	}{
+		{"fields", "Summarize {{.Title}} ({{.Duration}}s, {{.VideoID}})", video, "Summarize Door (12.5s, v1)", false},
+		{"title falls back to the ID", "About {{.Title}}", models.Video{VideoID: "v2"}, "About v2", false},
+		{"trimmed", "  plain question?\n", video, "plain question?", false},
+		{"unknown field", "{{.Nope}}", video, "", true},
+		{"bad syntax", "{{.Title", video, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(models.PromptTemplate{Name: "t", Text: tt.text}, tt.video)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTemplate(t *testing.T) {
+	if !IsTemplate("What is {{.Title}}?") {
+		t.Error("a question with {{ is a template")
+	}
+	if IsTemplate("What happens?") {
+		t.Error("a plain question is not a template")
+	}
+}
+
+func TestSaveAndList(t *testing.T) {
+	database := openDB(t)
+
+	if err := Save(database, "two words", "x"); err == nil {
+		t.Error("a name with a space should be rejected")
+	}
+	if err := Save(database, "broken", "{{.Title"); err == nil {
+		t.Error("an invalid template should be rejected")
+	}
+	if err := Save(database, "summarize", "Short summary of {{.Title}}"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(database, "alpha", "First {{.VideoID}}"); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := List(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tmpl := range templates {
+		names = append(names, tmpl.Name)
+	}
+	want := []string{"alpha", "on-screen-text", "scenes", "summarize"}
+	if len(names) != len(want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("names = %v, want %v", names, want)
+		}
+	}
+
+	// The saved template replaces the built-in one
+	summarize, err := Get(database, "summarize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summarize.BuiltIn || summarize.Text != "Short summary of {{.Title}}" {
+		t.Errorf("summarize = %+v, want the saved template", summarize)
+	}
+	if _, err := Get(database, "missing"); err == nil {
+		t.Error("Get of a missing template should fail")
+	}
+}
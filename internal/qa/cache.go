@@ -0,0 +1,115 @@
+package qa
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/db"
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+// Options control how a question is answered
+type Options struct {
+	CacheTTL time.Duration // how long an answer is reused, 0 to always ask the API
+	Refresh  bool          // ask the API even when a cached answer exists, then cache the new one
+}
+
+// AskVideo answers a question about a video, reusing a saved answer to the
+// same question when one is cached. Cached answers are dropped once older
+// than opts.CacheTTL, or when the indexing status or metadata of the video
+// changed since. A reused answer is returned with Cached set and isn't saved
+// to the history again. A video whose metadata couldn't be fetched bypasses
+// the cache, leaving the cached answers of the video as they are.
+func AskVideo(ctx context.Context, client *api.Client, database *db.DB, video models.Video, question string, opts Options) (*models.QueryHistory, error) {
+	if opts.CacheTTL <= 0 || !metadataKnown(video) {
+		return Ask(ctx, client, database, video.VideoID, VideoTitle(video), question)
+	}
+
+	key := CacheKey(video.VideoID, []models.ChatMessage{{Role: "user", Content: question}})
+	fingerprint := VideoFingerprint(video)
+
+	if !opts.Refresh {
+		if query := cachedAnswer(database, key, fingerprint, opts.CacheTTL); query != nil {
+			return query, nil
+		}
+	}
+
+	query, err := Ask(ctx, client, database, video.VideoID, VideoTitle(video), question)
+	if err != nil {
+		return query, err
+	}
+	// Only answers are worth reusing, not errors
+	if query.Error == nil || *query.Error == "" {
+		if err := database.SaveCacheEntry(key, query.ID, fingerprint); err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
+
+// metadataKnown reports whether video was fetched from the API rather than
+// made up from its ID, which every listed video has an indexing status for
+func metadataKnown(video models.Video) bool {
+	return video.IndexingStatus != ""
+}
+
+// cachedAnswer returns the saved query cached under key, nil when there is
+// none or it is stale. Stale entries are removed.
+func cachedAnswer(database *db.DB, key, fingerprint string, ttl time.Duration) *models.QueryHistory {
+	entry, err := database.GetCacheEntry(key)
+	if err != nil || entry == nil {
+		return nil
+	}
+	if entry.Fingerprint != fingerprint || time.Since(entry.CreatedAt) > ttl {
+		database.DeleteCacheEntry(key)
+		return nil
+	}
+
+	// The query may have been removed from the history since
+	query, err := database.GetQueryByID(entry.QueryID)
+	if err != nil {
+		database.DeleteCacheEntry(key)
+		return nil
+	}
+	query.Cached = true
+	return query
+}
+
+// normalizeQuestion makes questions that only differ in case, spacing or
+// final punctuation equal
+func normalizeQuestion(question string) string {
+	question = strings.ToLower(strings.Join(strings.Fields(question), " "))
+	return strings.TrimRight(question, "?!. ")
+}
+
+// CacheKey identifies a question about a video, with the conversation that
+// leads to it: every message is part of the key
+func CacheKey(videoID string, messages []models.ChatMessage) string {
+	h := sha256.New()
+	h.Write([]byte(videoID))
+	for _, m := range messages {
+		fmt.Fprintf(h, "\x00%s\x00%s", m.Role, normalizeQuestion(m.Content))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VideoFingerprint identifies the indexing status and metadata of a video,
+// so cached answers are dropped when the video changes. The thumbnail is
+// left out as its URL can change on every request.
+func VideoFingerprint(video models.Video) string {
+	md := video.Metadata
+	start := ""
+	if md.VideoStartTimestampUTCMs != nil {
+		start = fmt.Sprint(*md.VideoStartTimestampUTCMs)
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%g\x00%dx%d\x00%g\x00%s\x00%s",
+		video.IndexingStatus, video.IndexingType, md.Title, md.VideoName, md.Description,
+		md.Duration, md.Width, md.Height, md.AvgFPS, md.Source, start)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
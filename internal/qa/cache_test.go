@@ -0,0 +1,179 @@
+package qa
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/db"
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+func TestNormalizeQuestion(t *testing.T) {
+	tests := []struct {
+		question, want string
+	}{
+		{"Who rang the bell?", "who rang the bell"},
+		{"  who   rang\tthe bell ?! ", "who rang the bell"},
+		{"WHO RANG THE BELL...", "who rang the bell"},
+		{"who rang? the bell", "who rang? the bell"},
+	}
+	for _, tt := range tests {
+		if got := normalizeQuestion(tt.question); got != tt.want {
+			t.Errorf("normalizeQuestion(%q) = %q, want %q", tt.question, got, tt.want)
+		}
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	ask := func(content ...string) []models.ChatMessage {
+		var messages []models.ChatMessage
+		for _, c := range content {
+			messages = append(messages, models.ChatMessage{Role: "user", Content: c})
+		}
+		return messages
+	}
+	key := CacheKey("v1", ask("Who rang the bell?"))
+
+	if CacheKey("v1", ask("who rang  the bell")) != key {
+		t.Error("questions that only differ in case, spacing or punctuation should share a key")
+	}
+	if CacheKey("v2", ask("Who rang the bell?")) == key {
+		t.Error("the same question about another video should have its own key")
+	}
+	if CacheKey("v1", ask("Who rang the bell?", "And then?")) == key {
+		t.Error("every message of the conversation should be part of the key")
+	}
+	if CacheKey("v1", ask("Who rang", "the bell?")) == key {
+		t.Error("messages should not run into each other")
+	}
+}
+
+func TestVideoFingerprint(t *testing.T) {
+	start := int64(1700000000000)
+	video := models.Video{
+		VideoID:        "v1",
+		IndexingStatus: "indexed",
+		Metadata:       models.VideoMetadata{Title: "Front door", Duration: 60, VideoStartTimestampUTCMs: &start},
+	}
+	fingerprint := VideoFingerprint(video)
+
+	same := video
+	same.Metadata.Thumbnail = "https://example.com/other.jpg"
+	if VideoFingerprint(same) != fingerprint {
+		t.Error("a new thumbnail URL should keep the fingerprint")
+	}
+
+	changes := map[string]func(v *models.Video){
+		"indexing status": func(v *models.Video) { v.IndexingStatus = "indexing" },
+		"title":           func(v *models.Video) { v.Metadata.Title = "Back door" },
+		"duration":        func(v *models.Video) { v.Metadata.Duration = 61 },
+		"start time":      func(v *models.Video) { v.Metadata.VideoStartTimestampUTCMs = nil },
+	}
+	for name, change := range changes {
+		changed := video
+		change(&changed)
+		if VideoFingerprint(changed) == fingerprint {
+			t.Errorf("a new %s should change the fingerprint", name)
+		}
+	}
+}
+
+// newTestBackends returns a client of a fake API answering every question,
+// the number of questions it was asked and a history database
+func newTestBackends(t *testing.T) (*api.Client, *atomic.Int32, *db.DB) {
+	t.Helper()
+	var asked atomic.Int32
+	reka := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		asked.Add(1)
+		io.WriteString(w, `{"chat_response":"A cat walks by.","status":"success"}`)
+	}))
+	t.Cleanup(reka.Close)
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	return api.NewClientWithOptions("key", reka.URL, time.Minute), &asked, database
+}
+
+func TestAskVideoCache(t *testing.T) {
+	video := models.Video{VideoID: "v1", IndexingStatus: "indexed"}
+
+	tests := []struct {
+		name      string
+		opts      Options
+		change    func(v *models.Video)
+		wantAsked int32
+	}{
+		{"reused within the TTL", Options{CacheTTL: time.Hour}, nil, 1},
+		{"expired after the TTL", Options{CacheTTL: time.Nanosecond}, nil, 2},
+		{"disabled", Options{}, nil, 2},
+		{"refreshed", Options{CacheTTL: time.Hour, Refresh: true}, nil, 2},
+		{"video changed", Options{CacheTTL: time.Hour}, func(v *models.Video) { v.IndexingStatus = "failed" }, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, asked, database := newTestBackends(t)
+
+			first, err := AskVideo(context.Background(), client, database, video, "Who is there?", tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again := video
+			if tt.change != nil {
+				tt.change(&again)
+			}
+			second, err := AskVideo(context.Background(), client, database, again, "who is there", tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := asked.Load(); got != tt.wantAsked {
+				t.Errorf("the API was asked %d times, want %d", got, tt.wantAsked)
+			}
+			reused := tt.wantAsked == 1
+			if second.Cached != reused || (second.ID == first.ID) != reused {
+				t.Errorf("second answer: Cached %v, ID %d (first %d), want reused %v", second.Cached, second.ID, first.ID, reused)
+			}
+		})
+	}
+}
+
+func TestAskVideoWithoutMetadataBypassesCache(t *testing.T) {
+	client, asked, database := newTestBackends(t)
+	video := models.Video{VideoID: "v1", IndexingStatus: "indexed", Metadata: models.VideoMetadata{Title: "Front door"}}
+	opts := Options{CacheTTL: time.Hour}
+
+	first, err := AskVideo(context.Background(), client, database, video, "Who is there?", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The metadata couldn't be fetched: the API is asked and the cached
+	// answer is neither reused nor replaced
+	bare, err := AskVideo(context.Background(), client, database, models.Video{VideoID: "v1"}, "Who is there?", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bare.Cached || asked.Load() != 2 {
+		t.Errorf("without metadata: Cached %v, the API was asked %d times, want false and 2", bare.Cached, asked.Load())
+	}
+
+	again, err := AskVideo(context.Background(), client, database, video, "Who is there?", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Cached || again.ID != first.ID || asked.Load() != 2 {
+		t.Errorf("with metadata again: Cached %v, ID %d (first %d), the API was asked %d times, want the first answer reused",
+			again.Cached, again.ID, first.ID, asked.Load())
+	}
+}
@@ -0,0 +1,159 @@
+// Package qa asks questions about videos and records the answers in the
+// local history. It is shared by the TUI and the command line.
+package qa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/db"
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/prompts"
+	"github.com/fboucher/be-my-eyes/internal/wallclock"
+)
+
+// VideoTitle returns the title of a video, falling back to its ID
+func VideoTitle(v models.Video) string {
+	if v.Metadata.Title != "" {
+		return v.Metadata.Title
+	}
+	return v.VideoID
+}
+
+// Ask sends a question about a video to the API, parses the answer and saves
+// it to the history. The returned query holds the parsed answer and clips.
+// When ctx is cancelled while the API call is in flight, nothing is saved.
+func Ask(ctx context.Context, client *api.Client, database *db.DB, videoID, videoTitle, question string) (*models.QueryHistory, error) {
+	response, err := client.WithContext(ctx).AskQuestion(videoID, question)
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't record answers nobody is waiting for anymore
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	answer, clips := api.ParseChatResponse(response.ChatResponse)
+	query := &models.QueryHistory{
+		VideoID:    videoID,
+		VideoTitle: videoTitle,
+		Question:   question,
+		Answer:     answer,
+		Error:      response.Error,
+		Status:     response.Status,
+		VideoClips: clips,
+	}
+
+	// Save to database with video title, parsed answer, and video clips
+	id, err := database.SaveQuery(videoID, videoTitle, question, answer, clips, response.Error, response.Status)
+	if err != nil {
+		return query, err
+	}
+	query.ID = id
+	query.CreatedAt = time.Now()
+
+	return query, nil
+}
+
+// VideoQuestion returns the question to ask about a video. A question with
+// template actions, such as "What happens in {{.Title}}?", is rendered with
+// the metadata of the video, so a batch question can differ per video.
+func VideoQuestion(question string, video models.Video) (string, error) {
+	if !prompts.IsTemplate(question) {
+		return question, nil
+	}
+	return prompts.Render(models.PromptTemplate{Name: "batch", Text: question}, video)
+}
+
+// Result is the outcome of one video of a batch question
+type Result struct {
+	Video models.Video
+	Query *models.QueryHistory
+	Err   error
+}
+
+// AskMany asks the same question about several videos, rendered for each one
+// by VideoQuestion, running at most concurrency calls at once. Results are
+// returned in the order of videos; onResult, when set, is called as each one
+// completes.
+func AskMany(ctx context.Context, client *api.Client, database *db.DB, videos []models.Video, question string, concurrency int, opts Options, onResult func(Result)) []Result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]Result, len(videos))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for i, video := range videos {
+		wg.Add(1)
+		go func(i int, video models.Video) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = Result{Video: video, Err: ctx.Err()}
+				return
+			}
+
+			var query *models.QueryHistory
+			videoQuestion, err := VideoQuestion(question, video)
+			if err == nil {
+				query, err = AskVideo(ctx, client, database, video, videoQuestion, opts)
+			}
+			results[i] = Result{Video: video, Query: query, Err: err}
+
+			if onResult != nil {
+				mu.Lock()
+				onResult(results[i])
+				mu.Unlock()
+			}
+		}(i, video)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// ErrVideoStarts reports that a time-of-day search couldn't get the start
+// times of the videos from the API
+var ErrVideoStarts = errors.New("failed to get video start times")
+
+// SearchHistory returns the saved queries whose question or answer contains
+// text, optionally for one video. A time-of-day range such as "between 14:00
+// and 14:30" instead keeps the queries with a clip in that range, using the
+// start time of each video in loc.
+func SearchHistory(client *api.Client, database *db.DB, text, videoID string, loc *time.Location) ([]models.QueryHistory, error) {
+	r, isRange, err := wallclock.ParseRange(text)
+	if err != nil {
+		return nil, err
+	}
+	if !isRange {
+		return database.SearchHistory(text, videoID)
+	}
+
+	history, err := database.SearchHistory("", videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *models.VideosGetResponse
+	if videoID != "" {
+		response, err = client.GetVideos([]string{videoID})
+	} else {
+		response, err = client.GetAllVideos()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrVideoStarts, err)
+	}
+
+	return wallclock.FilterHistory(history, wallclock.Starts(response.Results, loc), r), nil
+}
@@ -0,0 +1,60 @@
+package qa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+func TestVideoQuestion(t *testing.T) {
+	video := models.Video{VideoID: "v1", Metadata: models.VideoMetadata{Title: "Front door"}}
+
+	tests := []struct {
+		question string
+		want     string
+		wantErr  bool
+	}{
+		{"Who rang the bell?", "Who rang the bell?", false},
+		{"Who rang the bell in {{.Title}}?", "Who rang the bell in Front door?", false},
+		{"What is in {{.VideoID}}?", "What is in v1?", false},
+		{"Who rang {{.Nope}}?", "", true},
+		{"Who rang {{.Title?", "", true},
+	}
+	for _, tt := range tests {
+		got, err := VideoQuestion(tt.question, video)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("VideoQuestion(%q) = %q, %v, want %q (error %v)", tt.question, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAskManyRendersTemplates(t *testing.T) {
+	client, asked, database := newTestBackends(t)
+	videos := []models.Video{
+		{VideoID: "v1", Metadata: models.VideoMetadata{Title: "Front door"}},
+		{VideoID: "v2", Metadata: models.VideoMetadata{Title: "Garden"}},
+	}
+
+	results := AskMany(context.Background(), client, database, videos, "What happens in {{.Title}}?", 2, Options{CacheTTL: time.Hour}, nil)
+	want := []string{"What happens in Front door?", "What happens in Garden?"}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("%s: %v", r.Video.VideoID, r.Err)
+		}
+		if r.Query.Question != want[i] {
+			t.Errorf("%s asked %q, want %q", r.Video.VideoID, r.Query.Question, want[i])
+		}
+	}
+
+	results = AskMany(context.Background(), client, database, videos, "What happens in {{.Nope}}?", 2, Options{}, nil)
+	for _, r := range results {
+		if r.Err == nil {
+			t.Errorf("%s: a template that doesn't render should fail", r.Video.VideoID)
+		}
+	}
+	if got := asked.Load(); got != 2 {
+		t.Errorf("the API was asked %d times, want 2", got)
+	}
+}
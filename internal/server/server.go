@@ -0,0 +1,311 @@
+// Package server exposes the application over a local HTTP/JSON API so other
+// tools can list videos, ask questions, upload and search the history. The
+// Reka API key stays in the server; callers authenticate with their own token.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/db"
+	"github.com/fboucher/be-my-eyes/internal/logging"
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/prompts"
+	"github.com/fboucher/be-my-eyes/internal/qa"
+	"github.com/fboucher/be-my-eyes/internal/wallclock"
+)
+
+// shutdownTimeout is how long in-flight requests get to finish on shutdown
+const shutdownTimeout = 30 * time.Second
+
+// Server serves the HTTP/JSON API
+type Server struct {
+	apiClient   *api.Client
+	database    *db.DB
+	token       string
+	location    *time.Location
+	cacheTTL    time.Duration
+	uploadIndex bool
+	logger      *log.Logger
+}
+
+// New creates a server. Requests must carry token as a bearer token; loc is
+// the time zone of wall-clock history searches, cacheTTL how long answers are
+// reused, 0 to always ask the API, and uploadIndex whether uploads that don't
+// say otherwise are indexed.
+func New(apiClient *api.Client, database *db.DB, token string, loc *time.Location, cacheTTL time.Duration, uploadIndex bool, logger *log.Logger) *Server {
+	return &Server{
+		apiClient:   apiClient,
+		database:    database,
+		token:       token,
+		location:    loc,
+		cacheTTL:    cacheTTL,
+		uploadIndex: uploadIndex,
+		logger:      logger,
+	}
+}
+
+// GenerateToken returns a random token for servers started without one
+func GenerateToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Handler returns the HTTP handler with routing, auth and request logging
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/videos", s.handleListVideos)
+	mux.HandleFunc("GET /api/videos/{id}", s.handleGetVideo)
+	mux.HandleFunc("POST /api/ask", s.handleAsk)
+	mux.HandleFunc("POST /api/upload", s.handleUpload)
+	mux.HandleFunc("GET /api/history", s.handleHistory)
+	mux.HandleFunc("GET /api/health", s.handleHealth)
+
+	return s.logRequests(s.authenticate(mux))
+}
+
+// ListenAndServe serves on addr until ctx is cancelled, then shuts down
+// gracefully
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.logger.Printf("shutting down, waiting up to %s for requests to finish", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authenticate rejects requests without the server token, sent as
+// "Authorization: Bearer <token>". The health check is left open for
+// monitoring.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs the method, path, status and duration of every request
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		s.logger.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// upstreamError reports a failed Reka API call without leaking the API key
+func (s *Server) upstreamError(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusBadGateway, logging.Redact(err.Error()))
+}
+
+// handleHealth reports that the server is up
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleListVideos returns every video of the library
+func (s *Server) handleListVideos(w http.ResponseWriter, r *http.Request) {
+	response, err := s.apiClient.WithContext(r.Context()).GetAllVideos()
+	if err != nil {
+		s.upstreamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response.Results)
+}
+
+// handleGetVideo returns one video
+func (s *Server) handleGetVideo(w http.ResponseWriter, r *http.Request) {
+	response, err := s.apiClient.WithContext(r.Context()).GetVideos([]string{r.PathValue("id")})
+	if err != nil {
+		s.upstreamError(w, err)
+		return
+	}
+	if len(response.Results) == 0 {
+		writeError(w, http.StatusNotFound, "video not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, response.Results[0])
+}
+
+// askRequest is the body of POST /api/ask
+type askRequest struct {
+	VideoID  string `json:"video_id"`
+	Question string `json:"question"`
+	Template string `json:"template"`
+}
+
+// handleAsk asks a question, typed or from a prompt template, and records
+// the answer in the history
+func (s *Server) handleAsk(w http.ResponseWriter, r *http.Request) {
+	var req askRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.VideoID == "" || (req.Question == "") == (req.Template == "") {
+		writeError(w, http.StatusBadRequest, "video_id and either question or template are required")
+		return
+	}
+
+	video := models.Video{VideoID: req.VideoID}
+	if response, err := s.apiClient.WithContext(r.Context()).GetVideos([]string{req.VideoID}); err == nil && len(response.Results) > 0 {
+		video = response.Results[0]
+	}
+
+	question := req.Question
+	if req.Template != "" {
+		t, err := prompts.Get(s.database, req.Template)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if question, err = prompts.Render(*t, video); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	query, err := qa.AskVideo(r.Context(), s.apiClient, s.database, video, question, qa.Options{CacheTTL: s.cacheTTL})
+	if err != nil {
+		s.upstreamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, query)
+}
+
+// uploadRequest is the body of POST /api/upload
+type uploadRequest struct {
+	VideoName string `json:"video_name"`
+	VideoURL  string `json:"video_url"`
+	Index     *bool  `json:"index"`
+}
+
+// handleUpload adds a video to the library from a URL
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	var req uploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.VideoName == "" || req.VideoURL == "" {
+		writeError(w, http.StatusBadRequest, "video_name and video_url are required")
+		return
+	}
+
+	index := s.uploadIndex
+	if req.Index != nil {
+		index = *req.Index
+	}
+
+	body, err := s.apiClient.WithContext(r.Context()).UploadVideo(req.VideoName, req.VideoURL, index)
+	if err != nil {
+		s.upstreamError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// handleHistory returns the saved queries, optionally for one video
+// (?video_id=) and matching a text or a time-of-day range (?q=)
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	videoID := r.URL.Query().Get("video_id")
+	text := r.URL.Query().Get("q")
+	if _, _, err := wallclock.ParseRange(text); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var history []models.QueryHistory
+	var err error
+	switch {
+	case text != "":
+		history, err = qa.SearchHistory(s.apiClient.WithContext(r.Context()), s.database, text, videoID, s.location)
+	case videoID != "":
+		history, err = s.database.GetHistoryByVideoID(videoID)
+	default:
+		history, err = s.database.GetAllHistory()
+	}
+	if errors.Is(err, qa.ErrVideoStarts) {
+		s.upstreamError(w, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, logging.Redact(err.Error()))
+		return
+	}
+
+	if history == nil {
+		history = []models.QueryHistory{}
+	}
+	writeJSON(w, http.StatusOK, history)
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/db"
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+const testToken = "secret-token"
+
+// newTestServer serves the API against a fake Reka API with one video,
+// reusing answers for cacheTTL and not indexing uploads by default. Getting
+// the video "broken" fails with the API key in the response; an upload
+// responds with the index field it was sent.
+func newTestServer(t *testing.T, cacheTTL time.Duration) (*httptest.Server, *db.DB) {
+	t.Helper()
+	reka := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/videos/get":
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), "broken") {
+				http.Error(w, "no access for "+r.Header.Get("X-Api-Key"), http.StatusInternalServerError)
+				return
+			}
+			io.WriteString(w, `{"results":[{"video_id":"v1","indexing_status":"indexed","metadata":{"title":"Front door"}}]}`)
+		case "/videos/upload":
+			fmt.Fprintf(w, `{"index":%q}`, r.FormValue("index"))
+		case "/qa/chat":
+			io.WriteString(w, `{"chat_response":"A cat walks by.","status":"success"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(reka.Close)
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	client := api.NewClientWithOptions("reka-key", reka.URL, time.Minute)
+	s := New(client, database, testToken, time.UTC, cacheTTL, false, log.New(io.Discard, "", 0))
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+	return srv, database
+}
+
+// call sends a request with the Authorization header, if any
+func call(t *testing.T, srv *httptest.Server, method, path, auth, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv, _ := newTestServer(t, 0)
+
+	tests := []struct {
+		name string
+		path string
+		auth string
+		want int
+	}{
+		{"no header", "/api/videos", "", http.StatusUnauthorized},
+		{"bare token", "/api/videos", testToken, http.StatusUnauthorized},
+		{"other scheme", "/api/videos", "Basic " + testToken, http.StatusUnauthorized},
+		{"wrong token", "/api/videos", "Bearer nope", http.StatusUnauthorized},
+		{"bearer token", "/api/videos", "Bearer " + testToken, http.StatusOK},
+		{"health is open", "/api/health", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := call(t, srv, "GET", tt.path, tt.auth, "")
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	srv, _ := newTestServer(t, 0)
+	auth := "Bearer " + testToken
+
+	resp := call(t, srv, "GET", "/api/videos", auth, "")
+	var videos []models.Video
+	if err := json.NewDecoder(resp.Body).Decode(&videos); err != nil {
+		t.Fatal(err)
+	}
+	if len(videos) != 1 || videos[0].VideoID != "v1" {
+		t.Fatalf("videos = %+v, want v1", videos)
+	}
+
+	resp = call(t, srv, "GET", "/api/videos/v1", auth, "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/videos/v1 status = %d", resp.StatusCode)
+	}
+
+	resp = call(t, srv, "POST", "/api/ask", auth, `{"video_id":"v1"}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("ask without question status = %d, want 400", resp.StatusCode)
+	}
+
+	resp = call(t, srv, "POST", "/api/ask", auth, `{"video_id":"v1","question":"Who is at the door?"}`)
+	var query models.QueryHistory
+	if err := json.NewDecoder(resp.Body).Decode(&query); err != nil {
+		t.Fatal(err)
+	}
+	if query.Answer != "A cat walks by." || query.VideoTitle != "Front door" {
+		t.Fatalf("ask = %+v", query)
+	}
+
+	resp = call(t, srv, "GET", "/api/history?video_id=v1", auth, "")
+	var history []models.QueryHistory
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Question != "Who is at the door?" {
+		t.Fatalf("history = %+v", history)
+	}
+
+	resp = call(t, srv, "GET", "/api/history?q=nothing", auth, "")
+	history = nil
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if history == nil || len(history) != 0 {
+		t.Errorf("history = %+v, want an empty list", history)
+	}
+}
+
+func TestAskReusesCachedAnswers(t *testing.T) {
+	srv, _ := newTestServer(t, time.Hour)
+	auth := "Bearer " + testToken
+
+	var queries []models.QueryHistory
+	for _, question := range []string{"Who is at the door?", "who is at the door"} {
+		resp := call(t, srv, "POST", "/api/ask", auth, `{"video_id":"v1","question":"`+question+`"}`)
+		var query models.QueryHistory
+		if err := json.NewDecoder(resp.Body).Decode(&query); err != nil {
+			t.Fatal(err)
+		}
+		queries = append(queries, query)
+	}
+
+	if queries[0].Cached || !queries[1].Cached || queries[1].ID != queries[0].ID {
+		t.Errorf("asks = %+v, want the second answer from the cache", queries)
+	}
+}
+
+func TestUploadIndexDefault(t *testing.T) {
+	srv, _ := newTestServer(t, 0)
+	auth := "Bearer " + testToken
+
+	tests := []struct {
+		index string
+		want  string
+	}{
+		{"", "false"},
+		{`,"index":true`, "true"},
+	}
+	for _, tt := range tests {
+		resp := call(t, srv, "POST", "/api/upload", auth, `{"video_name":"Door","video_url":"https://example.com/door.mp4"`+tt.index+`}`)
+		var body struct{ Index string }
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Index != tt.want {
+			t.Errorf("upload with %q sent index %q, want %q", tt.index, body.Index, tt.want)
+		}
+	}
+}
+
+func TestHistorySearchErrors(t *testing.T) {
+	srv, _ := newTestServer(t, 0)
+	auth := "Bearer " + testToken
+
+	resp := call(t, srv, "GET", "/api/history?q="+url.QueryEscape("between 25:00 and 26:00"), auth, "")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid range status = %d, want 400", resp.StatusCode)
+	}
+
+	resp = call(t, srv, "GET", "/api/history?video_id=broken&q="+url.QueryEscape("between 14:00 and 15:00"), auth, "")
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("failed start times status = %d, want 502", resp.StatusCode)
+	}
+	if strings.Contains(string(body), "reka-key") {
+		t.Errorf("response leaks the API key: %s", body)
+	}
+}
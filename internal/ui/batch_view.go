@@ -0,0 +1,229 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/qa"
+)
+
+// batchRun is one question asked across several videos
+type batchRun struct {
+	id       int
+	question string
+	videos   []models.Video
+	results  map[string]questionAskedMsg // by video ID
+	jobs     map[int]string              // video ID by job ID
+}
+
+// toggleMark adds or removes the selected video from the batch selection
+func (m *Model) toggleMark() tea.Cmd {
+	if m.selectedVideo == nil {
+		return nil
+	}
+
+	videoID := m.selectedVideo.VideoID
+	if m.markedVideos[videoID] {
+		delete(m.markedVideos, videoID)
+	} else {
+		m.markedVideos[videoID] = true
+	}
+	m.statusMessage = fmt.Sprintf("%d videos selected", len(m.markedVideos))
+	return m.updateLibraryList()
+}
+
+// markedVideoList returns the selected videos in library order
+func (m Model) markedVideoList() []models.Video {
+	var videos []models.Video
+	for _, v := range m.videos {
+		if m.markedVideos[v.VideoID] {
+			videos = append(videos, v)
+		}
+	}
+	return videos
+}
+
+// openBatchAsk opens the question dialog for the selected videos
+func (m *Model) openBatchAsk() {
+	if len(m.markedVideos) == 0 {
+		m.statusMessage = "Select videos with space first"
+		return
+	}
+	m.batchAsk = true
+	m.viewMode = QuestionDialogView
+	m.questionInput.Reset()
+	m.questionInput.Focus()
+}
+
+// startBatch fans a question out to every selected video as background
+// jobs. refresh asks the API even for cached answers.
+func (m *Model) startBatch(question string, refresh bool) tea.Cmd {
+	m.nextBatchID++
+	m.batch = &batchRun{
+		id:       m.nextBatchID,
+		question: question,
+		videos:   m.markedVideoList(),
+		results:  make(map[string]questionAskedMsg),
+		jobs:     make(map[int]string),
+	}
+	m.batchTable.SetCursor(0)
+
+	var cmds []tea.Cmd
+	for _, video := range m.batch.videos {
+		// A template from the prompt library is rendered for each video
+		videoQuestion, err := qa.VideoQuestion(question, video)
+		if err != nil {
+			m.batch.results[video.VideoID] = questionAskedMsg{batchID: m.batch.id, videoID: video.VideoID, err: err}
+			continue
+		}
+		id, cmd := m.askVideo(video, videoQuestion, m.batch.id, refresh)
+		m.batch.jobs[id] = video.VideoID
+		cmds = append(cmds, cmd)
+	}
+	m.refreshBatchTable()
+	m.statusMessage = fmt.Sprintf("Batch question queued for %d videos", len(m.batch.videos))
+	return tea.Batch(cmds...)
+}
+
+// recordBatchResult stores the answer of one video of the current batch
+func (m *Model) recordBatchResult(msg questionAskedMsg) {
+	if m.batch == nil || msg.batchID != m.batch.id {
+		return
+	}
+	m.batch.results[msg.videoID] = msg
+	m.refreshBatchTable()
+
+	if len(m.batch.results) == len(m.batch.videos) {
+		m.statusMessage = fmt.Sprintf("Batch question done for %d videos", len(m.batch.videos))
+	}
+}
+
+// cancelBatchJob records a cancelled job of the current batch, whose
+// answer will never come
+func (m *Model) cancelBatchJob(jobID int) {
+	if m.batch == nil {
+		return
+	}
+	videoID, ok := m.batch.jobs[jobID]
+	if !ok {
+		return
+	}
+	if _, answered := m.batch.results[videoID]; !answered {
+		m.recordBatchResult(questionAskedMsg{batchID: m.batch.id, videoID: videoID, err: context.Canceled})
+	}
+}
+
+// batchAnswer returns the status and answer of a video in the current batch
+func (m Model) batchAnswer(videoID string) (string, string) {
+	result, ok := m.batch.results[videoID]
+	switch {
+	case !ok:
+		return "pending", ""
+	case errors.Is(result.err, context.Canceled):
+		return "cancelled", ""
+	case result.err != nil:
+		return "failed", result.err.Error()
+	case result.query.Error != nil && *result.query.Error != "":
+		return "failed", *result.query.Error
+	case result.query.Cached:
+		return "cached", result.query.Answer
+	default:
+		return "answered", result.query.Answer
+	}
+}
+
+// refreshBatchTable sizes the comparison table and fills it from the batch
+func (m *Model) refreshBatchTable() {
+	width := m.width - 10
+	if width < 40 {
+		width = 40
+	}
+	height := m.height/2 - 4
+	if height < 3 {
+		height = 3
+	}
+
+	videoWidth, statusWidth := 24, 10
+	answerWidth := width - videoWidth - statusWidth - 6
+	m.batchTable.SetColumns([]table.Column{
+		{Title: "Video", Width: videoWidth},
+		{Title: "Status", Width: statusWidth},
+		{Title: "Answer", Width: answerWidth},
+	})
+	m.batchTable.SetWidth(width)
+	m.batchTable.SetHeight(height)
+
+	if m.batch == nil {
+		m.batchTable.SetRows(nil)
+		return
+	}
+
+	rows := make([]table.Row, len(m.batch.videos))
+	for i, video := range m.batch.videos {
+		status, answer := m.batchAnswer(video.VideoID)
+		// Only the first line fits in a cell
+		answer = strings.SplitN(strings.TrimSpace(answer), "\n", 2)[0]
+		rows[i] = table.Row{qa.VideoTitle(video), status, answer}
+	}
+	m.batchTable.SetRows(rows)
+}
+
+// viewBatch renders the per-video comparison of the last batch question
+func (m Model) viewBatch() string {
+	if m.batch == nil {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+			dialogStyle.Render(fmt.Sprintf("No batch question yet. Select videos with %s, then press %s.",
+				m.keys.Mark.Help().Key, m.keys.BatchAsk.Help().Key)))
+	}
+
+	title := fmt.Sprintf("Batch: %s (%d/%d)", m.batch.question, len(m.batch.results), len(m.batch.videos))
+
+	// Full answer of the highlighted video
+	detail := ""
+	if cursor := m.batchTable.Cursor(); cursor >= 0 && cursor < len(m.batch.videos) {
+		video := m.batch.videos[cursor]
+		_, answer := m.batchAnswer(video.VideoID)
+		detail = lipgloss.NewStyle().
+			Width(m.batchTable.Width()).
+			MaxHeight(m.height - m.batchTable.Height() - 12).
+			Render(qa.VideoTitle(video) + "\n\n" + answer)
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render(title),
+		"",
+		m.batchTable.View(),
+		"",
+		detail,
+		"",
+		footerStyle.Render(joinHelp(m.keys.batchHelp()...)),
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogStyle.Render(content),
+	)
+}
+
+// updateBatchView handles input in the batch comparison view
+func (m Model) updateBatchView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, m.keys.Back, m.keys.Batch) {
+		m.viewMode = MainView
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.batchTable, cmd = m.batchTable.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+func TestBatchCancelledJob(t *testing.T) {
+	m := NewModel(api.NewClient("key"), nil, nil)
+	m.videos = []models.Video{{VideoID: "v1"}, {VideoID: "v2"}}
+	m.markedVideos = map[string]bool{"v1": true, "v2": true}
+	m.startBatch("what happens?", false)
+
+	var jobID int
+	for id, videoID := range m.batch.jobs {
+		if videoID == "v2" {
+			jobID = id
+		}
+	}
+	if jobID == 0 {
+		t.Fatal("no job for v2")
+	}
+
+	m.jobs.cancel(jobID)
+	m.cancelBatchJob(jobID)
+
+	if status, _ := m.batchAnswer("v2"); status != "cancelled" {
+		t.Errorf("v2 status = %q, want cancelled", status)
+	}
+	if status, _ := m.batchAnswer("v1"); status != "pending" {
+		t.Errorf("v1 status = %q, want pending", status)
+	}
+
+	// Once every row is settled, the batch is done
+	m.recordBatchResult(questionAskedMsg{batchID: m.batch.id, videoID: "v1", query: &models.QueryHistory{Answer: "a dog"}})
+	if m.statusMessage != "Batch question done for 2 videos" {
+		t.Errorf("status = %q", m.statusMessage)
+	}
+}
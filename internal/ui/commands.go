@@ -0,0 +1,202 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// command is an action of the menu and the command palette
+type command struct {
+	action      string // what runCommand does
+	title       string
+	description string
+	binding     string               // keybinding action whose key runs it too, empty for none
+	detail      func(m Model) string // description depending on the state, over description
+	unavailable func(m Model) string // why the command can't run now, empty when it can
+}
+
+// commands are every action of the TUI, in menu order
+var commands = []command{
+	{action: "help", title: "Help", description: "Show help screen", binding: "help"},
+	{action: "about", title: "About", description: "About this application"},
+	{action: "jobs", title: "Jobs", description: "Background questions and uploads", binding: "jobs"},
+	{action: "errors", title: "Errors and Warnings", description: "Inspect, copy or clear the errors of the session", binding: "errors"},
+	{action: "logs", title: "View Log", description: "Recent log lines, API requests with --debug", binding: "logs"},
+	{action: "refresh", title: "Refresh Library", description: "Refresh the video library", binding: "refresh"},
+	{action: "search", title: "Search Videos", description: "Find moments across every indexed video", binding: "search"},
+	{action: "upload", title: "Upload Video", description: "Add a video to the library from its URL", binding: "upload"},
+	{action: "ask", title: "Ask a Question", description: "Ask a question about the selected video", binding: "ask",
+		unavailable: needsVideo},
+	{action: "mark", title: "Select for Batch", description: "Add or remove the video from the batch selection", binding: "mark",
+		unavailable: needsLibraryVideo},
+	{action: "video-history", title: "Video Q&A", description: "All past questions about the selected video", binding: "video_history",
+		unavailable: needsVideo},
+	{action: "delete", title: "Delete Video", description: "Remove the video from the Reka library", binding: "delete",
+		unavailable: needsLibraryVideo},
+	{action: "export", title: "Export Clips", description: "Export the clips as subtitles or chapters", binding: "export",
+		unavailable: func(m Model) string {
+			if m.activeSection != HistorySection || m.selectedQuery == nil {
+				return "select a query in History"
+			}
+			if len(m.selectedQuery.VideoClips) == 0 {
+				return "the selected query has no clips"
+			}
+			return ""
+		}},
+	{action: "batch-ask", title: "Batch Ask", description: "Ask a question about the selected videos", binding: "batch_ask",
+		detail: func(m Model) string {
+			return "Ask a question about the " + plural(len(m.markedVideos), "selected video")
+		},
+		unavailable: func(m Model) string {
+			if len(m.markedVideos) == 0 {
+				return "select videos with space first"
+			}
+			return ""
+		}},
+	{action: "batch-results", title: "Batch Results", description: "Compare the answers of the last batch question", binding: "batch",
+		unavailable: func(m Model) string {
+			if m.batch == nil {
+				return "no batch question asked yet"
+			}
+			return ""
+		}},
+	{action: "scope", title: "Toggle History Scope", binding: "scope",
+		detail: func(m Model) string {
+			if m.historyScoped {
+				return "Show all videos in History"
+			}
+			return "Show only the selected video in History"
+		}},
+	{action: "filter", title: "Filter List", description: "Fuzzy filter the active list", binding: "filter",
+		unavailable: func(m Model) string {
+			if m.activeSection == StatusSection {
+				return "select Videos or History"
+			}
+			return ""
+		}},
+	{action: "switch", title: "Next Section", description: "Move to the next section", binding: "switch"},
+	{action: "api-key", title: "Change API Key", description: "Enter and check a new Reka API key"},
+	{action: "profile", title: "Switch Profile",
+		detail: func(m Model) string {
+			return "Use another account of the config file (now: " + m.config.ActiveProfile() + ")"
+		}},
+	{action: "theme", title: "Switch Theme",
+		detail: func(m Model) string {
+			return "Use the " + m.nextTheme() + " theme, and keep it (now: " + m.config.UI.Theme.Name + ")"
+		}},
+	{action: "quit", title: "Quit", description: "Exit the application", binding: "quit"},
+}
+
+// needsVideo makes a command available once a video is selected
+func needsVideo(m Model) string {
+	if m.selectedVideo == nil {
+		return "select a video first"
+	}
+	return ""
+}
+
+// needsLibraryVideo makes a command available on the video selected in Videos
+func needsLibraryVideo(m Model) string {
+	if m.activeSection != LibrarySection || m.selectedVideo == nil {
+		return "select a video in Videos"
+	}
+	return ""
+}
+
+// describe returns the description of the command in the current state
+func (c command) describe(m Model) string {
+	if c.detail != nil {
+		return c.detail(m)
+	}
+	return c.description
+}
+
+// reason returns why the command can't run now, empty when it can
+func (c command) reason(m Model) string {
+	if c.unavailable == nil {
+		return ""
+	}
+	return c.unavailable(m)
+}
+
+// key returns the key bound to the command, empty for none
+func (c command) key(m Model) string {
+	if c.binding == "" {
+		return ""
+	}
+	b, ok := m.keys.action(c.binding)
+	if !ok {
+		return ""
+	}
+	return b.Help().Key
+}
+
+// openUpload starts the upload dialog
+func (m *Model) openUpload() {
+	m.viewMode = UploadDialogView
+	m.uploadFocus = 0
+	m.uploadTitleInput.Reset()
+	m.uploadTitleInput.Focus()
+	m.uploadURLInput.Reset()
+	m.uploadURLInput.Blur()
+}
+
+// runCommand runs a command of the menu or the command palette, from the
+// main view
+func (m *Model) runCommand(action string) tea.Cmd {
+	m.viewMode = MainView
+
+	switch action {
+	case "quit":
+		return tea.Quit
+	case "help":
+		m.viewMode = HelpView
+	case "about":
+		m.viewMode = AboutView
+	case "jobs":
+		m.viewMode = JobsView
+	case "errors":
+		m.openNotices()
+	case "logs":
+		m.openLogs()
+	case "refresh":
+		m.isLoading = true
+		m.statusMessage = "Refreshing..."
+		return m.refreshLibrary()
+	case "search":
+		return m.openSearch()
+	case "upload":
+		m.openUpload()
+	case "ask":
+		if m.selectedVideo != nil {
+			m.viewMode = QuestionDialogView
+			m.questionInput.Reset()
+			m.questionInput.Focus()
+		}
+	case "mark":
+		return m.toggleMark()
+	case "video-history":
+		return m.openVideoHistory()
+	case "delete":
+		m.openDeleteVideo()
+	case "export":
+		m.openExport()
+	case "batch-ask":
+		m.openBatchAsk()
+	case "batch-results":
+		m.viewMode = BatchView
+	case "scope":
+		return m.toggleHistoryScope()
+	case "filter":
+		return m.updateSectionList(m.activeSection, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	case "switch":
+		m.activeSection = (m.activeSection + 1) % 3
+		m.updateDetailView()
+	case "api-key":
+		return m.openSetup(false)
+	case "profile":
+		return m.openProfilePicker()
+	case "theme":
+		return m.switchTheme()
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/qa"
+)
+
+// deleteChoice is an answer of the delete confirmation
+type deleteChoice int
+
+const (
+	deleteKeepHistory deleteChoice = iota
+	deletePurgeHistory
+	deleteCancel
+)
+
+// deleteState is the confirmation of a video deletion
+type deleteState struct {
+	video  models.Video
+	cursor deleteChoice
+}
+
+// videoDeletedMsg is sent when a delete job completes
+type videoDeletedMsg struct {
+	videoID string
+	title   string
+	purged  int64 // queries removed from the history
+	err     error
+}
+
+// openDeleteVideo asks to confirm the deletion of the selected video
+func (m *Model) openDeleteVideo() {
+	if m.activeSection != LibrarySection || m.selectedVideo == nil {
+		m.statusMessage = "Select a video in Videos to delete it"
+		return
+	}
+	m.deletion = deleteState{video: *m.selectedVideo, cursor: deleteKeepHistory}
+	m.viewMode = DeleteDialogView
+}
+
+// deleteVideo queues the deletion of a video from the library as a
+// background job, removing its history too when purge is set
+func (m Model) deleteVideo(video models.Video, purge bool) tea.Cmd {
+	title := qa.VideoTitle(video)
+	_, cmd := m.jobs.add("delete", "Delete: "+title, func(ctx context.Context) (tea.Msg, error) {
+		if err := m.apiClient.WithContext(ctx).DeleteVideo(video.VideoID); err != nil {
+			return videoDeletedMsg{videoID: video.VideoID, title: title, err: err}, err
+		}
+		var purged int64
+		if purge {
+			n, err := m.database.DeleteHistoryByVideoID(video.VideoID)
+			if err != nil {
+				err = fmt.Errorf("video deleted, but its history wasn't: %w", err)
+				return videoDeletedMsg{videoID: video.VideoID, title: title, err: err}, err
+			}
+			purged = n
+		}
+		return videoDeletedMsg{videoID: video.VideoID, title: title, purged: purged}, nil
+	})
+	return cmd
+}
+
+// forgetVideo drops a deleted video from the selection and the batch marks
+func (m *Model) forgetVideo(videoID string) {
+	delete(m.markedVideos, videoID)
+	if m.selectedVideo != nil && m.selectedVideo.VideoID == videoID {
+		m.selectedVideo = nil
+	}
+}
+
+// viewDeleteDialog renders the delete confirmation
+func (m Model) viewDeleteDialog() string {
+	var b strings.Builder
+
+	video := m.deletion.video
+	b.WriteString(titleStyle.Render("Delete Video"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("Delete %q from the Reka library?\n", qa.VideoTitle(video)))
+	b.WriteString(footerStyle.Render("This can't be undone; the video must be uploaded again to ask about it."))
+	b.WriteString("\n\n")
+
+	questions := m.questionCounts[video.VideoID]
+	saved := fmt.Sprintf("%d saved questions", questions)
+	if questions == 1 {
+		saved = "1 saved question"
+	}
+	choices := []string{
+		fmt.Sprintf("Delete the video, keep its %s", saved),
+		fmt.Sprintf("Delete the video, its %s and search results", saved),
+		"Cancel",
+	}
+	for i, choice := range choices {
+		if deleteChoice(i) == m.deletion.cursor {
+			b.WriteString(focusedStyle.Render("> "+choice) + "\n")
+		} else {
+			b.WriteString("  " + choice + "\n")
+		}
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, b.String(), footerStyle.Render("↑↓: select, enter: confirm, esc: cancel"))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialogStyle.Render(content))
+}
+
+// updateDeleteDialog handles input in the delete confirmation
+func (m Model) updateDeleteDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.viewMode = MainView
+
+	case "up", "k":
+		if m.deletion.cursor > deleteKeepHistory {
+			m.deletion.cursor--
+		}
+
+	case "down", "j", "tab":
+		if m.deletion.cursor < deleteCancel {
+			m.deletion.cursor++
+		}
+
+	case "enter":
+		m.viewMode = MainView
+		if m.deletion.cursor == deleteCancel {
+			return m, nil
+		}
+		video := m.deletion.video
+		m.statusMessage = fmt.Sprintf("Deleting %s...", qa.VideoTitle(video))
+		return m, m.deleteVideo(video, m.deletion.cursor == deletePurgeHistory)
+	}
+	return m, nil
+}
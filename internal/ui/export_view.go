@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/export"
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/wallclock"
+)
+
+// exportedMsg is sent when an export file is written
+type exportedMsg struct {
+	path  string
+	clips int
+	err   error
+}
+
+// exportItem implements list.Item for the export format picker
+type exportItem struct {
+	format      export.Format
+	description string
+}
+
+func (e exportItem) Title() string       { return string(e.format) }
+func (e exportItem) Description() string { return e.description }
+func (e exportItem) FilterValue() string { return string(e.format) }
+
+// newExportList creates the export format picker
+func newExportList() list.Model {
+	items := make([]list.Item, len(export.Formats))
+	for i, f := range export.Formats {
+		items[i] = exportItem{format: f.Format, description: f.Description}
+	}
+
+	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
+	l.Title = "Export Clips"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+	return l
+}
+
+// openExport opens the export format picker for the selected query
+func (m *Model) openExport() {
+	if m.activeSection != HistorySection || m.selectedQuery == nil {
+		m.statusMessage = "Select a query in History to export its clips"
+		return
+	}
+	if len(m.selectedQuery.VideoClips) == 0 {
+		m.statusMessage = "This query has no video clips to export"
+		return
+	}
+	m.viewMode = ExportDialogView
+}
+
+// libraryVideo returns a video of the library, with only its ID when it
+// isn't loaded
+func (m Model) libraryVideo(videoID string) models.Video {
+	video, _ := m.loadedVideo(videoID)
+	return video
+}
+
+// loadedVideo returns a video of the library and whether it is loaded, with
+// only its ID when it isn't
+func (m Model) loadedVideo(videoID string) (models.Video, bool) {
+	for _, v := range m.videos {
+		if v.VideoID == videoID {
+			return v, true
+		}
+	}
+	return models.Video{VideoID: videoID}, false
+}
+
+// exportFileName builds a file name from the video title and query ID
+func exportFileName(q models.QueryHistory, ext string) string {
+	return fmt.Sprintf("%s-q%d%s", export.SafeName(q.VideoTitle), q.ID, ext)
+}
+
+// exportQuery writes the clips of a query in the given format to the
+// current directory, with wall-clock times when toggled on in the dialog.
+// Cut formats read the video from its library URL and merge overlapping
+// clips; the export command has the other options.
+func (m Model) exportQuery(q models.QueryHistory, format export.Format) tea.Cmd {
+	video := m.libraryVideo(q.VideoID)
+	start, _ := wallclock.Start(video.Metadata, m.location)
+	opts := export.Options{Merge: true, WallClock: m.exportWallClock}
+	return func() tea.Msg {
+		source := export.Source{
+			Path:     video.URL,
+			Title:    q.VideoTitle,
+			Duration: video.Metadata.Duration,
+			FPS:      video.Metadata.AvgFPS,
+			Start:    start,
+			Clips:    q.VideoClips,
+		}
+		content, count, err := export.Export(format, []export.Source{source}, opts)
+		if err != nil {
+			return exportedMsg{err: err}
+		}
+
+		perm := os.FileMode(0644)
+		if format == export.FFmpegScript {
+			perm = 0755
+		}
+		// The status shows where the file went
+		path := exportFileName(q, format.Extension())
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if err := os.WriteFile(path, []byte(content), perm); err != nil {
+			return exportedMsg{err: fmt.Errorf("failed to write %s: %w", path, err)}
+		}
+		return exportedMsg{path: path, clips: count}
+	}
+}
+
+// viewExportDialog renders the export format picker
+func (m Model) viewExportDialog() string {
+	m.exportList.SetSize(70, 18)
+
+	wallClock := "off"
+	if m.exportWallClock {
+		wallClock = "on"
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.exportList.View(),
+		"Wall-clock times in subtitles and chapters: "+wallClock,
+		footerStyle.Render(joinHelp(m.keys.exportHelp()...)),
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogStyle.Render(content),
+	)
+}
+
+// updateExportDialog handles input in the export format picker
+func (m Model) updateExportDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.viewMode = MainView
+		return m, nil
+
+	case key.Matches(msg, m.keys.WallClock):
+		m.exportWallClock = !m.exportWallClock
+		return m, nil
+
+	case key.Matches(msg, m.keys.ExportSubmit):
+		m.viewMode = MainView
+		if item, ok := m.exportList.SelectedItem().(exportItem); ok && m.selectedQuery != nil {
+			if _, ok := m.loadedVideo(m.selectedQuery.VideoID); !ok {
+				m.reportWarning("Exporting clips", fmt.Sprintf("video %s is not loaded: its clips are exported without wall-clock times and not checked against its duration", m.selectedQuery.VideoID))
+			}
+			m.statusMessage = "Exporting..."
+			return m, m.exportQuery(*m.selectedQuery, item.format)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.exportList, cmd = m.exportList.Update(msg)
+	return m, cmd
+}
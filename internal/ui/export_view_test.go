@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fboucher/be-my-eyes/internal/export"
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+func TestExportQueryReportsFullPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	m := NewModel(nil, nil, nil)
+	q := models.QueryHistory{
+		ID:         7,
+		VideoID:    "v1",
+		VideoTitle: "Front door",
+		VideoClips: []models.VideoClip{{StartTime: 1, EndTime: 3, Info: "A cat walks by"}},
+	}
+	msg := m.exportQuery(q, export.SRT)().(exportedMsg)
+	if msg.err != nil {
+		t.Fatal(msg.err)
+	}
+
+	want := filepath.Join(dir, exportFileName(q, export.SRT.Extension()))
+	if msg.path != want {
+		t.Errorf("path = %q, want %q", msg.path, want)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExportDialogWallClockToggle(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	start := time.Date(2026, 3, 14, 14, 0, 0, 0, time.UTC).UnixMilli()
+	m := NewModel(nil, nil, nil)
+	m.location = time.UTC
+	m.videos = []models.Video{{VideoID: "v1", Metadata: models.VideoMetadata{Title: "Front door", Duration: 60, VideoStartTimestampUTCMs: &start}}}
+	m.selectedQuery = &models.QueryHistory{
+		ID:         7,
+		VideoID:    "v1",
+		VideoTitle: "Front door",
+		VideoClips: []models.VideoClip{{StartTime: 1, EndTime: 3, Info: "A cat walks by"}},
+	}
+	press := func(msg tea.KeyMsg) tea.Cmd {
+		t.Helper()
+		m.viewMode = ExportDialogView
+		updated, cmd := m.updateExportDialog(msg)
+		m = updated.(Model)
+		return cmd
+	}
+	exported := func(cmd tea.Cmd) string {
+		t.Helper()
+		msg := cmd().(exportedMsg)
+		if msg.err != nil {
+			t.Fatal(msg.err)
+		}
+		data, err := os.ReadFile(msg.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if content := exported(press(tea.KeyMsg{Type: tea.KeyEnter})); strings.Contains(content, "14:00:01") {
+		t.Errorf("export without wall-clock times = %q", content)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if !m.exportWallClock || !strings.Contains(m.viewExportDialog(), "Wall-clock times in subtitles and chapters: on") {
+		t.Fatal("w should turn wall-clock times on")
+	}
+	if content := exported(press(tea.KeyMsg{Type: tea.KeyEnter})); !strings.Contains(content, "14:00:01-14:00:03") {
+		t.Errorf("export with wall-clock times = %q, want the time of day of the clip", content)
+	}
+	if len(m.notices.notices) != 0 {
+		t.Errorf("notices = %+v, want none for a loaded video", m.notices.notices)
+	}
+
+	// A video that isn't loaded loses its start time: say so
+	m.videos = nil
+	exported(press(tea.KeyMsg{Type: tea.KeyEnter}))
+	if len(m.notices.notices) != 1 || !strings.Contains(m.notices.notices[0].message, "without wall-clock times") {
+		t.Errorf("notices = %+v, want a warning about the missing start time", m.notices.notices)
+	}
+}
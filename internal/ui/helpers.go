@@ -1,36 +1,152 @@
 package ui
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/wallclock"
 )
 
 // updateSizes updates the sizes of UI components when window is resized
 func (m *Model) updateSizes() {
-	// Sizes will be calculated dynamically in the view
+	// Most sizes are calculated dynamically in the view; scrollable screens
+	// keep their own viewport so they need to know the window size
+	m.refreshVideoHistoryView()
+	m.refreshBatchTable()
 }
 
-// updateLibraryList updates the library list with current videos
-func (m *Model) updateLibraryList() {
+// updateLibraryList updates the library list with current videos.
+// The returned command re-runs the active filter, if any.
+func (m *Model) updateLibraryList() tea.Cmd {
 	items := make([]list.Item, len(m.videos))
 	for i, v := range m.videos {
-		items[i] = videoItem{video: v}
+		items[i] = videoItem{
+			video:     v,
+			questions: m.questionCounts[v.VideoID],
+			marked:    m.markedVideos[v.VideoID],
+		}
 	}
-	m.libraryList.SetItems(items)
+	cmd := m.libraryList.SetItems(items)
 
 	// Select first item if none selected
 	if len(items) > 0 && m.selectedVideo == nil {
 		m.selectedVideo = &m.videos[0]
 		m.updateDetailView()
 	}
+
+	return routeListCmd(LibrarySection, cmd)
+}
+
+// updateHistoryList updates the history list with current history, or with
+// the history of the selected video when the section is scoped
+func (m *Model) updateHistoryList() tea.Cmd {
+	history := m.history
+	if m.historyScoped {
+		history = m.videoHistory
+	}
+	items := make([]list.Item, len(history))
+	spans := make([][]wallclock.Span, len(history))
+	for i, h := range history {
+		spans[i] = m.clipSpans(h)
+		items[i] = historyItem{query: h, spans: spans[i], cached: m.cachedQueries[h.ID]}
+	}
+	m.historyList.Filter = historyFilter(spans)
+	return routeListCmd(HistorySection, m.historyList.SetItems(items))
+}
+
+// historyFilter returns the history list filter. A time-of-day range such as
+// "between 14:00 and 14:30" keeps the queries with a clip in the range,
+// using the wall-clock spans of each item; anything else is matched with the
+// default fuzzy filter.
+func historyFilter(spans [][]wallclock.Span) list.FilterFunc {
+	return func(term string, targets []string) []list.Rank {
+		r, ok, err := wallclock.ParseRange(term)
+		if !ok {
+			return list.DefaultFilter(term, targets)
+		}
+		if err != nil {
+			return nil
+		}
+
+		var ranks []list.Rank
+		for i := range targets {
+			if i >= len(spans) {
+				break
+			}
+			for _, span := range spans[i] {
+				if r.Overlaps(span) {
+					ranks = append(ranks, list.Rank{Index: i})
+					break
+				}
+			}
+		}
+		return ranks
+	}
+}
+
+// clipSpans returns the wall-clock spans of the clips of a query, nil when
+// the video doesn't record when it started
+func (m Model) clipSpans(q models.QueryHistory) []wallclock.Span {
+	start, ok := wallclock.Start(m.libraryVideo(q.VideoID).Metadata, m.location)
+	if !ok {
+		return nil
+	}
+	spans := make([]wallclock.Span, len(q.VideoClips))
+	for i, clip := range q.VideoClips {
+		spans[i] = wallclock.ClipSpan(start, clip)
+	}
+	return spans
 }
 
-// updateHistoryList updates the history list with current history
-func (m *Model) updateHistoryList() {
-	items := make([]list.Item, len(m.history))
-	for i, h := range m.history {
-		items[i] = historyItem{query: h}
+// renderClips lists the clips of a query, with their wall-clock times when
+// the video records when it started
+func (m Model) renderClips(q models.QueryHistory) string {
+	spans := m.clipSpans(q)
+
+	var b strings.Builder
+	for i, clip := range q.VideoClips {
+		if spans != nil {
+			b.WriteString(fmt.Sprintf("  • %s (%.1fs - %.1fs) %s\n", spans[i], clip.StartTime, clip.EndTime, clip.Info))
+		} else {
+			b.WriteString(fmt.Sprintf("  • %.1fs - %.1fs %s\n", clip.StartTime, clip.EndTime, clip.Info))
+		}
 	}
-	m.historyList.SetItems(items)
+	return b.String()
+}
+
+// showQuery selects a query in the History section and shows its details
+func (m *Model) showQuery(id int) tea.Cmd {
+	cmd := m.updateHistoryList()
+	m.historyList.ResetFilter()
+	for i, item := range m.historyList.Items() {
+		if h, ok := item.(historyItem); ok && h.query.ID == id {
+			m.historyList.Select(i)
+			m.activeSection = HistorySection
+			m.updateSelectedQuery()
+			m.updateDetailView()
+			break
+		}
+	}
+	return cmd
+}
+
+// countQuestions tallies the saved queries of every video in the history
+func (m *Model) countQuestions() {
+	m.questionCounts = make(map[string]int)
+	for _, h := range m.history {
+		m.questionCounts[h.VideoID]++
+	}
+}
+
+// historyTitle returns the History section title, naming the video when scoped
+func (m Model) historyTitle() string {
+	if m.historyScoped && m.selectedVideo != nil {
+		return "History: " + videoItem{video: *m.selectedVideo}.Title()
+	}
+	return "History"
 }
 
 // updateSelectedVideo updates the currently selected video from the list
@@ -56,43 +172,128 @@ func (m *Model) updateDetailView() {
 	m.detailsView.GotoTop()
 }
 
-// updateMenuList updates the menu list based on active section
+// updateMenuList lists the commands available in the current state
 func (m *Model) updateMenuList() {
 	var items []list.Item
-
-	// Always available actions
-	items = append(items, menuItem{
-		title:       "Help",
-		description: "Show help screen",
-		action:      "help",
-	})
-
-	items = append(items, menuItem{
-		title:       "About",
-		description: "About this application",
-		action:      "about",
-	})
-
-	items = append(items, menuItem{
-		title:       "Refresh Library",
-		description: "Refresh the video library",
-		action:      "refresh",
-	})
-
-	// Library-specific actions
-	if m.activeSection == LibrarySection && m.selectedVideo != nil {
+	for _, c := range commands {
+		if c.reason(*m) != "" {
+			continue
+		}
 		items = append(items, menuItem{
-			title:       "Ask a Question",
-			description: "Ask a question about the selected video",
-			action:      "ask",
+			title:       c.title,
+			description: c.describe(*m),
+			action:      c.action,
 		})
 	}
+	m.menuList.SetItems(items)
+}
 
-	items = append(items, menuItem{
-		title:       "Quit",
-		description: "Exit the application",
-		action:      "quit",
-	})
+// listMsg wraps a message produced by one of the section lists so it can be
+// routed back to the list that issued it (e.g. async filter results)
+type listMsg struct {
+	section Section
+	msg     tea.Msg
+}
 
-	m.menuList.SetItems(items)
+// templateListMsg wraps a message produced by the template picker list
+type templateListMsg struct {
+	msg tea.Msg
+}
+
+// routeListCmd tags the messages produced by a list command with the section
+// of the list that issued it
+func routeListCmd(section Section, cmd tea.Cmd) tea.Cmd {
+	return wrapListCmd(cmd, func(msg tea.Msg) tea.Msg { return listMsg{section: section, msg: msg} })
+}
+
+// routeTemplateListCmd tags the messages produced by a template picker list
+// command
+func routeTemplateListCmd(cmd tea.Cmd) tea.Cmd {
+	return wrapListCmd(cmd, func(msg tea.Msg) tea.Msg { return templateListMsg{msg: msg} })
+}
+
+// wrapListCmd wraps every message produced by a list command, including
+// those of batches
+func wrapListCmd(cmd tea.Cmd, wrap func(tea.Msg) tea.Msg) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg := cmd()
+		switch msg := msg.(type) {
+		case nil:
+			return nil
+		case tea.BatchMsg:
+			cmds := make(tea.BatchMsg, len(msg))
+			for i, c := range msg {
+				cmds[i] = wrapListCmd(c, wrap)
+			}
+			return cmds
+		default:
+			return wrap(msg)
+		}
+	}
+}
+
+// updateSectionList forwards a message to the list of the given section and
+// refreshes the selection it drives
+func (m *Model) updateSectionList(section Section, msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	switch section {
+	case LibrarySection:
+		m.libraryList, cmd = m.libraryList.Update(msg)
+		m.updateSelectedVideo()
+	case HistorySection:
+		m.historyList, cmd = m.historyList.Update(msg)
+		m.updateSelectedQuery()
+	default:
+		return nil
+	}
+	m.updateDetailView()
+
+	cmd = routeListCmd(section, cmd)
+	// Follow the selection when History is scoped to the selected video
+	if m.historyScoped && m.selectedVideo != nil && m.selectedVideo.VideoID != m.videoHistoryID {
+		m.videoHistoryID = m.selectedVideo.VideoID
+		cmd = tea.Batch(cmd, m.loadVideoHistory(m.videoHistoryID))
+	}
+	return cmd
+}
+
+// activeListSettingFilter reports whether the list of the active section is
+// currently capturing keystrokes for its filter
+func (m Model) activeListSettingFilter() bool {
+	switch m.activeSection {
+	case LibrarySection:
+		return m.libraryList.SettingFilter()
+	case HistorySection:
+		return m.historyList.SettingFilter()
+	}
+	return false
+}
+
+// activeListFiltered reports whether the list of the active section has a
+// filter applied or being edited
+func (m Model) activeListFiltered() bool {
+	switch m.activeSection {
+	case LibrarySection:
+		return m.libraryList.FilterState() != list.Unfiltered
+	case HistorySection:
+		return m.historyList.FilterState() != list.Unfiltered
+	}
+	return false
+}
+
+// withMatchCount decorates a list title and filter prompt with the number of
+// items matching the current filter
+func withMatchCount(l list.Model, title string) list.Model {
+	l.Title = title
+	l.FilterInput.Prompt = "Filter: "
+	if l.FilterState() == list.Unfiltered {
+		return l
+	}
+	count := fmt.Sprintf("%d/%d", len(l.VisibleItems()), len(l.Items()))
+	l.Title = fmt.Sprintf("%s (%s)", title, count)
+	l.FilterInput.Prompt = fmt.Sprintf("Filter (%s): ", count)
+	return l
 }
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/wallclock"
+)
+
+func TestVideoItemFilterValue(t *testing.T) {
+	item := videoItem{video: models.Video{
+		VideoID:        "vid-42",
+		IndexingStatus: "indexed",
+		Metadata:       models.VideoMetadata{Title: "Front door", Description: "camera 2"},
+	}}
+
+	got := item.FilterValue()
+	want := "Front door vid-42 camera 2 indexed"
+	if got != want {
+		t.Errorf("FilterValue() = %q, want %q", got, want)
+	}
+}
+
+func TestHistoryFilter(t *testing.T) {
+	day := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+	spans := [][]wallclock.Span{
+		{{Start: at(14, 10), End: at(14, 12)}},
+		{{Start: at(9, 0), End: at(9, 5)}},
+		nil, // video without a start time
+	}
+	targets := []string{"who rang the bell", "delivery truck", "bell again"}
+	filter := historyFilter(spans)
+
+	tests := []struct {
+		name string
+		term string
+		want []int
+	}{
+		{"time range", "between 14:00 and 14:30", []int{0}},
+		{"dash range", "09:00-09:01", []int{1}},
+		{"no clip in range", "from 20:00 to 21:00", nil},
+		{"invalid time", "25:00-26:00", nil},
+		{"fuzzy text", "bell", []int{0, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, rank := range filter(tt.term, targets) {
+				got = append(got, rank.Index)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("matches = %v, want %v", got, tt.want)
+			}
+			seen := map[int]bool{}
+			for _, i := range got {
+				seen[i] = true
+			}
+			for _, i := range tt.want {
+				if !seen[i] {
+					t.Fatalf("matches = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRouteListCmd(t *testing.T) {
+	if routeListCmd(LibrarySection, nil) != nil {
+		t.Fatal("routeListCmd(nil) should be nil")
+	}
+
+	type filtered struct{}
+	msg := routeListCmd(HistorySection, func() tea.Msg { return filtered{} })()
+	routed, ok := msg.(listMsg)
+	if !ok || routed.section != HistorySection {
+		t.Fatalf("msg = %#v, want a listMsg for History", msg)
+	}
+	if _, ok := routed.msg.(filtered); !ok {
+		t.Errorf("routed msg = %#v, want the list message", routed.msg)
+	}
+
+	batch := routeListCmd(LibrarySection, tea.Batch(
+		func() tea.Msg { return filtered{} },
+		func() tea.Msg { return filtered{} },
+	))()
+	cmds, ok := batch.(tea.BatchMsg)
+	if !ok || len(cmds) != 2 {
+		t.Fatalf("batch = %#v, want 2 commands", batch)
+	}
+	for _, c := range cmds {
+		if routed, ok := c().(listMsg); !ok || routed.section != LibrarySection {
+			t.Errorf("batched msg not routed to Videos")
+		}
+	}
+}
+
+func TestLibraryFilterShowsMatchCount(t *testing.T) {
+	m := NewModel(nil, nil, nil)
+	m.viewMode = MainView
+	m.videos = []models.Video{
+		{VideoID: "a", Metadata: models.VideoMetadata{Title: "Cats playing"}},
+		{VideoID: "b", Metadata: models.VideoMetadata{Title: "Dogs running"}},
+		{VideoID: "c", Metadata: models.VideoMetadata{Title: "More cats"}},
+	}
+	m.libraryList.SetSize(40, 20)
+	m.updateLibraryList()
+
+	m.libraryList.SetFilterText("cats")
+	m.libraryList.SetFilterState(list.FilterApplied)
+
+	l := withMatchCount(m.libraryList, "Videos")
+	if l.Title != "Videos (2/3)" {
+		t.Errorf("Title = %q, want %q", l.Title, "Videos (2/3)")
+	}
+	if l.FilterInput.Prompt != "Filter (2/3): " {
+		t.Errorf("Prompt = %q, want %q", l.FilterInput.Prompt, "Filter (2/3): ")
+	}
+}
@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// JobState represents the lifecycle state of a background job
+type JobState int
+
+const (
+	JobQueued JobState = iota
+	JobRunning
+	JobDone
+	JobFailed
+	JobCancelled
+)
+
+func (s JobState) String() string {
+	switch s {
+	case JobQueued:
+		return "queued"
+	case JobRunning:
+		return "running"
+	case JobDone:
+		return "done"
+	case JobFailed:
+		return "failed"
+	case JobCancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// finished reports whether the job reached a terminal state
+func (s JobState) finished() bool {
+	return s == JobDone || s == JobFailed || s == JobCancelled
+}
+
+// jobFunc is the work of a job. It runs outside of the Bubble Tea loop and
+// returns the message to deliver to Update once the job is done.
+type jobFunc func(ctx context.Context) (tea.Msg, error)
+
+// Job is a question, upload or other API call running in the background
+type Job struct {
+	ID       int
+	Kind     string // ask, upload, ...
+	Label    string
+	State    JobState
+	Err      error
+	Queued   time.Time
+	Started  time.Time
+	Finished time.Time
+
+	run      jobFunc
+	cancel   context.CancelFunc
+	inFlight bool // its work hasn't returned yet, even if cancelled
+}
+
+// Elapsed returns how long the job has been running, or ran
+func (j *Job) Elapsed() time.Duration {
+	switch {
+	case j.Started.IsZero():
+		return 0
+	case j.Finished.IsZero():
+		return time.Since(j.Started)
+	default:
+		return j.Finished.Sub(j.Started)
+	}
+}
+
+// jobFinishedMsg is sent when the work of a job returns
+type jobFinishedMsg struct {
+	id     int
+	result tea.Msg
+	err    error
+}
+
+// jobManager queues background jobs and runs at most limit of them at once.
+// It is only touched from Update, so it needs no locking; the work itself runs
+// in Bubble Tea commands.
+type jobManager struct {
+	jobs   []*Job
+	nextID int
+	limit  int
+}
+
+// newJobManager creates a job manager running at most limit jobs at once
+func newJobManager(limit int) *jobManager {
+	if limit < 1 {
+		limit = 1
+	}
+	return &jobManager{nextID: 1, limit: limit}
+}
+
+// add queues a new job and returns the commands starting whatever can run now
+func (jm *jobManager) add(kind, label string, run jobFunc) (*Job, tea.Cmd) {
+	job := &Job{
+		ID:     jm.nextID,
+		Kind:   kind,
+		Label:  label,
+		State:  JobQueued,
+		Queued: time.Now(),
+		run:    run,
+	}
+	jm.nextID++
+	jm.jobs = append(jm.jobs, job)
+	return job, jm.schedule()
+}
+
+// get returns the job with the given ID, or nil
+func (jm *jobManager) get(id int) *Job {
+	for _, job := range jm.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// schedule starts queued jobs, oldest first, until the limit is reached. A
+// cancelled job counts against the limit until its work returns.
+func (jm *jobManager) schedule() tea.Cmd {
+	var cmds []tea.Cmd
+	running := 0
+	for _, job := range jm.jobs {
+		if job.inFlight {
+			running++
+		}
+	}
+	for _, job := range jm.jobs {
+		if running >= jm.limit {
+			break
+		}
+		if job.State != JobQueued {
+			continue
+		}
+		cmds = append(cmds, jm.start(job))
+		running++
+	}
+	return tea.Batch(cmds...)
+}
+
+// start marks a job as running and returns the command doing its work
+func (jm *jobManager) start(job *Job) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	job.State = JobRunning
+	job.Started = time.Now()
+	job.cancel = cancel
+	job.inFlight = true
+
+	id, run := job.ID, job.run
+	return func() tea.Msg {
+		result, err := run(ctx)
+		return jobFinishedMsg{id: id, result: result, err: err}
+	}
+}
+
+// finish records the outcome of a job and starts the next queued ones. It
+// returns the job's result message, or nil when the job was cancelled.
+func (jm *jobManager) finish(msg jobFinishedMsg) (tea.Msg, tea.Cmd) {
+	job := jm.get(msg.id)
+	if job != nil {
+		job.inFlight = false
+	}
+	if job == nil || job.State == JobCancelled {
+		return nil, jm.schedule()
+	}
+
+	job.Finished = time.Now()
+	job.cancel()
+	if msg.err != nil {
+		job.State = JobFailed
+		job.Err = msg.err
+	} else {
+		job.State = JobDone
+	}
+	return msg.result, jm.schedule()
+}
+
+// cancel stops a queued or running job. A running job's requests are
+// aborted through its context, and its result is discarded when it comes
+// back.
+func (jm *jobManager) cancel(id int) tea.Cmd {
+	job := jm.get(id)
+	if job == nil || job.State.finished() {
+		return nil
+	}
+
+	if job.cancel != nil {
+		job.cancel()
+	}
+	job.State = JobCancelled
+	job.Finished = time.Now()
+	return jm.schedule()
+}
+
+// clearFinished drops done, failed and cancelled jobs from the list. A
+// cancelled job stays until its work returns, as it still counts against the
+// limit.
+func (jm *jobManager) clearFinished() {
+	active := jm.jobs[:0]
+	for _, job := range jm.jobs {
+		if !job.State.finished() || job.inFlight {
+			active = append(active, job)
+		}
+	}
+	jm.jobs = active
+}
+
+// count returns the number of jobs in the given state
+func (jm *jobManager) count(state JobState) int {
+	n := 0
+	for _, job := range jm.jobs {
+		if job.State == state {
+			n++
+		}
+	}
+	return n
+}
+
+// active reports whether any job is queued or running
+func (jm *jobManager) active() bool {
+	return jm.count(JobQueued)+jm.count(JobRunning) > 0
+}
+
+// summary describes the pending jobs in a few words for the Status section
+func (jm *jobManager) summary() string {
+	running, queued := jm.count(JobRunning), jm.count(JobQueued)
+	if running+queued == 0 {
+		return ""
+	}
+	if queued == 0 {
+		return fmt.Sprintf("Jobs: %d running", running)
+	}
+	return fmt.Sprintf("Jobs: %d running, %d queued", running, queued)
+}
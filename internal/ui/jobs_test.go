@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// blockingJob is a job whose work waits for its context, then returns
+func blockingJob(ctx context.Context) (tea.Msg, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestJobManagerLimit(t *testing.T) {
+	jm := newJobManager(2)
+	for i := 0; i < 4; i++ {
+		jm.add("ask", "q", blockingJob)
+	}
+
+	if got := jm.count(JobRunning); got != 2 {
+		t.Fatalf("running = %d, want 2", got)
+	}
+	if got := jm.count(JobQueued); got != 2 {
+		t.Fatalf("queued = %d, want 2", got)
+	}
+	if got := jm.summary(); got != "Jobs: 2 running, 2 queued" {
+		t.Errorf("summary = %q", got)
+	}
+}
+
+func TestJobManagerFinishStartsNext(t *testing.T) {
+	jm := newJobManager(1)
+	first, _ := jm.add("ask", "first", blockingJob)
+	second, _ := jm.add("ask", "second", blockingJob)
+
+	result, cmd := jm.finish(jobFinishedMsg{id: first.ID, result: "answer"})
+	if result != "answer" {
+		t.Errorf("result = %v, want the job's message", result)
+	}
+	if cmd == nil || second.State != JobRunning {
+		t.Fatalf("second job state = %s, want running", second.State)
+	}
+	if first.State != JobDone {
+		t.Errorf("first job state = %s, want done", first.State)
+	}
+
+	jm.finish(jobFinishedMsg{id: second.ID, err: errors.New("boom")})
+	if second.State != JobFailed || second.Err == nil {
+		t.Errorf("second job = %s (%v), want failed", second.State, second.Err)
+	}
+	if jm.active() {
+		t.Error("no job should be active")
+	}
+}
+
+func TestJobManagerCancelRunning(t *testing.T) {
+	jm := newJobManager(1)
+	ctxs := make(chan context.Context, 1)
+	running, cmd := jm.add("upload", "u", func(ctx context.Context) (tea.Msg, error) {
+		ctxs <- ctx
+		<-ctx.Done()
+		return "uploaded", ctx.Err()
+	})
+	queued, _ := jm.add("upload", "next", blockingJob)
+
+	// Run the work of the first job in the background, as Bubble Tea would
+	msgs := make(chan tea.Msg, 1)
+	go func() { msgs <- cmd() }()
+	ctx := <-ctxs
+
+	jm.cancel(running.ID)
+	if running.State != JobCancelled {
+		t.Fatalf("state = %s, want cancelled", running.State)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("the work's context wasn't cancelled")
+	}
+
+	// The cancelled work is still returning: the next job must wait
+	if queued.State != JobQueued {
+		t.Fatalf("next job state = %s before the cancelled work returned, want queued", queued.State)
+	}
+	jm.clearFinished()
+	if jm.get(running.ID) == nil {
+		t.Fatal("the cancelled job was cleared while its work was in flight")
+	}
+
+	result, _ := jm.finish((<-msgs).(jobFinishedMsg))
+	if result != nil {
+		t.Errorf("result = %v, want nil for a cancelled job", result)
+	}
+	if running.State != JobCancelled {
+		t.Errorf("state = %s after the work returned, want cancelled", running.State)
+	}
+	if queued.State != JobRunning {
+		t.Errorf("next job state = %s, want running", queued.State)
+	}
+}
+
+func TestJobManagerCancelQueued(t *testing.T) {
+	jm := newJobManager(1)
+	jm.add("ask", "running", blockingJob)
+	queued, _ := jm.add("ask", "queued", blockingJob)
+
+	jm.cancel(queued.ID)
+	if queued.State != JobCancelled {
+		t.Fatalf("state = %s, want cancelled", queued.State)
+	}
+	if jm.cancel(queued.ID) != nil {
+		t.Error("cancelling a finished job should do nothing")
+	}
+
+	jm.clearFinished()
+	if jm.get(queued.ID) != nil {
+		t.Error("the cancelled queued job wasn't cleared")
+	}
+	if len(jm.jobs) != 1 {
+		t.Errorf("jobs = %d, want the running one", len(jm.jobs))
+	}
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// jobStateIcons maps job states to the marker shown in the Jobs panel
+var jobStateIcons = map[JobState]string{
+	JobQueued:    "…",
+	JobDone:      "✓",
+	JobFailed:    "✗",
+	JobCancelled: "−",
+}
+
+// viewJobs renders the background jobs panel
+func (m Model) viewJobs() string {
+	var b strings.Builder
+
+	if len(m.jobs.jobs) == 0 {
+		b.WriteString("No jobs yet. Questions and uploads run here in the background.")
+	}
+
+	width := m.width - 16
+	if width < 30 {
+		width = 30
+	}
+
+	for i, job := range m.jobs.jobs {
+		icon := jobStateIcons[job.State]
+		if job.State == JobRunning {
+			icon = m.spinner.View()
+		}
+
+		elapsed := ""
+		if !job.Started.IsZero() {
+			elapsed = fmt.Sprintf(" %.1fs", job.Elapsed().Seconds())
+		}
+
+		line := fmt.Sprintf("%s #%d %-9s %s%s", icon, job.ID, job.State, job.Label, elapsed)
+		if job.State == JobFailed && job.Err != nil {
+			line += " - " + job.Err.Error()
+		}
+		line = lipgloss.NewStyle().MaxWidth(width).Render(line)
+
+		if i == m.jobsCursor {
+			line = focusedStyle.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	title := fmt.Sprintf("Jobs (max %d at once)", m.jobs.limit)
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render(title),
+		"",
+		b.String(),
+		footerStyle.Render(joinHelp(m.keys.jobsHelp()...)),
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogStyle.Render(content),
+	)
+}
+
+// updateJobsView handles input in the jobs panel
+func (m Model) updateJobsView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if key.Matches(msg, m.keys.Jobs) {
+		m.viewMode = MainView
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.viewMode = MainView
+
+	case key.Matches(msg, m.keys.Up):
+		if m.jobsCursor > 0 {
+			m.jobsCursor--
+		}
+
+	case key.Matches(msg, m.keys.Down):
+		if m.jobsCursor < len(m.jobs.jobs)-1 {
+			m.jobsCursor++
+		}
+
+	case key.Matches(msg, m.keys.CancelJob):
+		if m.jobsCursor < len(m.jobs.jobs) {
+			job := m.jobs.jobs[m.jobsCursor]
+			if !job.State.finished() {
+				cmd = m.jobs.cancel(job.ID)
+				m.cancelBatchJob(job.ID)
+				m.statusMessage = fmt.Sprintf("Job #%d cancelled", job.ID)
+			}
+		}
+
+	case key.Matches(msg, m.keys.ClearFinished):
+		m.jobs.clearFinished()
+		m.jobsCursor = 0
+	}
+
+	return m, cmd
+}
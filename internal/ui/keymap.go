@@ -0,0 +1,240 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/config"
+)
+
+// keyMap holds the keys of the main view and the dialogs. Actions of
+// config.DefaultKeybindings take their key from the ui.keybindings
+// settings; navigation keys are fixed.
+type keyMap struct {
+	// Main view navigation
+	Up          key.Binding
+	Down        key.Binding
+	Select      key.Binding
+	ClearFilter key.Binding
+
+	// Main view actions
+	Quit         key.Binding
+	Switch       key.Binding
+	Filter       key.Binding
+	Refresh      key.Binding
+	Ask          key.Binding
+	Upload       key.Binding
+	Scope        key.Binding
+	VideoHistory key.Binding
+	Mark         key.Binding
+	BatchAsk     key.Binding
+	Batch        key.Binding
+	Jobs         key.Binding
+	Export       key.Binding
+	Delete       key.Binding
+	Search       key.Binding
+	Logs         key.Binding
+	Errors       key.Binding
+	Palette      key.Binding
+	Menu         key.Binding
+	Help         key.Binding
+
+	// Question dialog
+	Submit        key.Binding
+	SubmitRefresh key.Binding
+	Template      key.Binding
+	Cancel        key.Binding
+
+	// Upload dialog
+	NextField    key.Binding
+	PrevField    key.Binding
+	UploadSubmit key.Binding
+
+	// Export dialog
+	WallClock    key.Binding
+	ExportSubmit key.Binding
+
+	// Jobs, error center and batch comparison
+	Browse        key.Binding // help of Up and Down in these lists
+	Back          key.Binding
+	CancelJob     key.Binding
+	ClearFinished key.Binding
+	CopyNotice    key.Binding
+	CopyNotices   key.Binding
+	Dismiss       key.Binding
+	ClearNotices  key.Binding
+}
+
+// newKeyMap builds the key map from the configured keybindings, action ->
+// key. Missing actions keep their default key.
+func newKeyMap(bindings map[string]string) keyMap {
+	bind := func(action, desc string) key.Binding {
+		k, ok := bindings[action]
+		if !ok || k == "" {
+			k = config.DefaultKeybindings[action]
+		}
+		if k == "space" {
+			k = " "
+		}
+		return key.NewBinding(key.WithKeys(k), key.WithHelp(keyLabel(k), desc))
+	}
+	fixed := func(desc, label string, keys ...string) key.Binding {
+		return key.NewBinding(key.WithKeys(keys...), key.WithHelp(label, desc))
+	}
+
+	return keyMap{
+		Up:          fixed("move up, scroll details", "↑/k", "up", "k"),
+		Down:        fixed("move down, scroll details", "↓/j", "down", "j"),
+		Select:      fixed("select item", "enter", "enter"),
+		ClearFilter: fixed("clear the filter", "esc", "esc"),
+
+		Quit:         bind("quit", "quit"),
+		Switch:       bind("switch", "next section"),
+		Filter:       bind("filter", "filter the active list"),
+		Refresh:      bind("refresh", "refresh the library"),
+		Ask:          bind("ask", "ask about the selected video"),
+		Upload:       bind("upload", "upload a video from its URL"),
+		Scope:        bind("scope", "History: all videos or the selected one"),
+		VideoHistory: bind("video_history", "all Q&As of the selected video"),
+		Mark:         bind("mark", "select the video for a batch question"),
+		BatchAsk:     bind("batch_ask", "ask about every selected video"),
+		Batch:        bind("batch", "compare the last batch answers"),
+		Jobs:         bind("jobs", "background jobs"),
+		Export:       bind("export", "export the clips of the query"),
+		Delete:       bind("delete", "delete the selected video"),
+		Search:       bind("search", "search every indexed video"),
+		Logs:         bind("logs", "show the log"),
+		Errors:       bind("errors", "errors and warnings"),
+		Palette:      bind("palette", "command palette"),
+		Menu:         bind("menu", "menu"),
+		Help:         bind("help", "help"),
+
+		Submit:        bind("question_submit", "ask, reusing a cached answer"),
+		SubmitRefresh: bind("question_refresh", "ask again even if cached"),
+		Template:      bind("question_template", "insert a prompt template"),
+		Cancel:        fixed("cancel", "esc", "esc"),
+
+		NextField:    fixed("next field", "tab", "tab"),
+		PrevField:    fixed("previous field", "shift+tab", "shift+tab"),
+		UploadSubmit: fixed("upload", "enter", "enter"),
+
+		WallClock:    fixed("wall-clock times on/off", "w", "w"),
+		ExportSubmit: fixed("export to the current directory", "enter", "enter"),
+
+		Browse:        fixed("select", "↑↓", "up", "down", "k", "j"),
+		Back:          fixed("back", "esc", "esc", "q"),
+		CancelJob:     fixed("cancel", "c", "c"),
+		ClearFinished: fixed("clear finished", "d", "d"),
+		CopyNotice:    fixed("copy", "c", "c"),
+		CopyNotices:   fixed("copy all", "C", "C"),
+		Dismiss:       fixed("dismiss", "d", "d"),
+		ClearNotices:  fixed("clear all", "X", "X"),
+	}
+}
+
+// keyLabel returns how a key is shown in the help
+func keyLabel(k string) string {
+	switch k {
+	case " ":
+		return "space"
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	}
+	return k
+}
+
+// action returns the binding of a config.DefaultKeybindings action
+func (k keyMap) action(name string) (key.Binding, bool) {
+	b, ok := map[string]key.Binding{
+		"quit": k.Quit, "switch": k.Switch, "filter": k.Filter, "refresh": k.Refresh,
+		"ask": k.Ask, "upload": k.Upload, "scope": k.Scope, "video_history": k.VideoHistory,
+		"mark": k.Mark, "batch_ask": k.BatchAsk, "batch": k.Batch, "jobs": k.Jobs,
+		"export": k.Export, "delete": k.Delete, "search": k.Search, "logs": k.Logs,
+		"errors": k.Errors, "palette": k.Palette, "menu": k.Menu, "help": k.Help,
+		"question_submit": k.Submit, "question_refresh": k.SubmitRefresh, "question_template": k.Template,
+	}[name]
+	return b, ok
+}
+
+// ShortHelp returns the keys of the main view footer
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		k.Upload, k.Refresh, k.Ask, k.Menu, k.Palette, k.Quit, k.Filter, k.Scope,
+		k.VideoHistory, k.Mark, k.BatchAsk, k.Export, k.Search, k.Jobs, k.Switch, k.Up, k.Down,
+	}
+}
+
+// FullHelp returns the keys of the main view, one group per help column
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Switch, k.Select, k.Filter, k.ClearFilter},
+		{
+			k.Refresh, k.Ask, k.Scope, k.VideoHistory, k.Mark, k.BatchAsk, k.Batch, k.Export,
+			k.Delete, k.Search, k.Upload, k.Jobs, k.Logs, k.Errors, k.Menu, k.Palette, k.Help, k.Quit,
+		},
+	}
+}
+
+// questionHelp returns the keys of the question dialog
+func (k keyMap) questionHelp() []key.Binding {
+	return []key.Binding{k.Submit, k.SubmitRefresh, k.Template, k.Cancel}
+}
+
+// uploadHelp returns the keys of the upload dialog
+func (k keyMap) uploadHelp() []key.Binding {
+	return []key.Binding{k.NextField, k.PrevField, k.UploadSubmit, k.Cancel}
+}
+
+// exportHelp returns the keys of the export dialog
+func (k keyMap) exportHelp() []key.Binding {
+	return []key.Binding{k.ExportSubmit, k.WallClock, k.Back}
+}
+
+// jobsHelp returns the keys of the jobs panel
+func (k keyMap) jobsHelp() []key.Binding {
+	return []key.Binding{k.Browse, k.CancelJob, k.ClearFinished, k.Back}
+}
+
+// noticesHelp returns the keys of the error center
+func (k keyMap) noticesHelp() []key.Binding {
+	return []key.Binding{k.Browse, k.CopyNotice, k.CopyNotices, k.Dismiss, k.ClearNotices, k.Back}
+}
+
+// batchHelp returns the keys of the batch comparison
+func (k keyMap) batchHelp() []key.Binding {
+	return []key.Binding{k.Browse, k.Back}
+}
+
+// newHelp creates the help renderer, in the colors of the theme
+func newHelp(theme config.ThemeConfig) help.Model {
+	h := help.New()
+	h.ShortSeparator = ", "
+	muted := lipgloss.NewStyle().Foreground(color(theme.Muted))
+	accent := lipgloss.NewStyle().Foreground(color(theme.Accent))
+	h.Styles.ShortKey = muted
+	h.Styles.ShortDesc = muted
+	h.Styles.ShortSeparator = muted
+	h.Styles.Ellipsis = muted
+	h.Styles.FullKey = accent
+	h.Styles.FullDesc = lipgloss.NewStyle()
+	h.Styles.FullSeparator = muted
+	return h
+}
+
+// helpSection renders a titled column of keys
+func (m Model) helpSection(title string, bindings []key.Binding) string {
+	return titleStyle.Render(title) + "\n" + m.help.FullHelpView([][]key.Binding{bindings})
+}
+
+// joinHelp writes bindings as "key: desc" pairs, for dialog footers
+func joinHelp(bindings ...key.Binding) string {
+	parts := make([]string, len(bindings))
+	for i, b := range bindings {
+		parts[i] = b.Help().Key + ": " + b.Help().Desc
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fboucher/be-my-eyes/internal/config"
+)
+
+func TestNewKeyMapOverrides(t *testing.T) {
+	keys := newKeyMap(map[string]string{
+		"ask":             "ctrl+a",
+		"mark":            "space",
+		"refresh":         "", // empty keeps the default
+		"question_submit": "alt+enter",
+	})
+
+	tests := []struct {
+		name    string
+		binding key.Binding
+		msg     tea.KeyMsg
+		want    bool
+	}{
+		{"new ask key", keys.Ask, tea.KeyMsg{Type: tea.KeyCtrlA}, true},
+		{"old ask key", keys.Ask, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}, false},
+		{"space", keys.Mark, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}, true},
+		{"empty override", keys.Refresh, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")}, true},
+		{"missing action", keys.Quit, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}, true},
+		{"dialog key", keys.Submit, tea.KeyMsg{Type: tea.KeyEnter, Alt: true}, true},
+		{"fixed key", keys.Up, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")}, true},
+	}
+	for _, tt := range tests {
+		if got := key.Matches(tt.msg, tt.binding); got != tt.want {
+			t.Errorf("%s: %q matches = %v, want %v", tt.name, tt.msg, got, tt.want)
+		}
+	}
+
+	if got := keys.Mark.Help().Key; got != "space" {
+		t.Errorf("mark help key = %q, want space", got)
+	}
+	for action := range config.DefaultKeybindings {
+		if _, ok := keys.action(action); !ok {
+			t.Errorf("no binding for the %s action", action)
+		}
+	}
+}
+
+func TestHelpFollowsKeybindings(t *testing.T) {
+	cfg := config.Default()
+	cfg.UI.Keybindings["ask"] = "ctrl+a"
+	cfg.UI.Keybindings["question_template"] = "ctrl+o"
+	m := NewModel(nil, nil, cfg)
+	m.width, m.height = 200, 60
+
+	footer := m.help.ShortHelpView(m.keys.ShortHelp())
+	if !strings.Contains(footer, "ctrl+a ask about the selected video") {
+		t.Errorf("footer = %q, want the new ask key", footer)
+	}
+	if got := joinHelp(m.keys.questionHelp()...); !strings.Contains(got, "ctrl+o: insert a prompt template") {
+		t.Errorf("question footer = %q, want the new template key", got)
+	}
+}
+
+func TestDialogsFollowKeybindings(t *testing.T) {
+	cfg := config.Default()
+	cfg.UI.Keybindings["mark"] = "m"
+	cfg.UI.Keybindings["batch_ask"] = "ctrl+b"
+	cfg.UI.Keybindings["batch"] = "b"
+	m := NewModel(nil, nil, cfg)
+	m.width, m.height = 200, 60
+
+	if view := m.viewBatch(); !strings.Contains(view, "Select videos with m, then press ctrl+b.") {
+		t.Errorf("batch view = %q, want the configured keys", view)
+	}
+
+	m.viewMode = BatchView
+	updated, _ := m.updateBatchView(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	if got := updated.(Model).viewMode; got != MainView {
+		t.Errorf("the configured batch key left view mode %v, want the main view", got)
+	}
+
+	m.viewMode = NoticesView
+	if view := m.viewNotices(); !strings.Contains(view, joinHelp(m.keys.noticesHelp()...)) {
+		t.Errorf("error center = %q, want its footer from the key map", view)
+	}
+	if view := m.viewJobs(); !strings.Contains(view, "c: cancel, d: clear finished, esc: back") {
+		t.Errorf("jobs panel = %q, want its footer from the key map", view)
+	}
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/logging"
+)
+
+// openLogs shows the recent log lines, scrolled to the newest
+func (m *Model) openLogs() {
+	m.viewMode = LogView
+	m.refreshLogView()
+	m.logView.GotoBottom()
+}
+
+// refreshLogView sizes the log viewport and renders the recent log lines
+func (m *Model) refreshLogView() {
+	width := m.width - 10
+	if width < 20 {
+		width = 20
+	}
+	height := m.height - 10
+	if height < 5 {
+		height = 5
+	}
+	m.logView.Width = width
+	m.logView.Height = height
+
+	lines := logging.Recent()
+	content := strings.Join(lines, "\n")
+	if len(lines) == 0 {
+		content = fmt.Sprintf("Nothing logged yet at level %s. Start with --debug to log every API request.", m.config.Log.Level)
+		if m.config.Log.Level == logging.LevelOff {
+			content = "Logging is off. Start with --debug, or set log.level, to log the API requests."
+		}
+	}
+	m.logView.SetContent(lipgloss.NewStyle().Width(width).Render(content))
+}
+
+// viewLogs renders the log viewer
+func (m Model) viewLogs() string {
+	title := fmt.Sprintf("Log (level %s)", m.config.Log.Level)
+	file := "Logging is off"
+	if path := logging.Path(); path != "" {
+		file = "Written to " + path
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render(title),
+		footerStyle.Render(file),
+		"",
+		m.logView.View(),
+		"",
+		footerStyle.Render("↑↓/pgup/pgdn: scroll, r: reload, esc: back"),
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogStyle.Render(content),
+	)
+}
+
+// updateLogView handles input in the log viewer
+func (m Model) updateLogView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, key.NewBinding(key.WithKeys("esc", "q")), m.keys.Logs):
+		m.viewMode = MainView
+		return m, nil
+	case key.Matches(msg, key.NewBinding(key.WithKeys("r"))):
+		m.refreshLogView()
+		m.logView.GotoBottom()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.logView, cmd = m.logView.Update(msg)
+	return m, cmd
+}
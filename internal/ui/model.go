@@ -3,16 +3,20 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/config"
 	"github.com/fboucher/be-my-eyes/internal/db"
 	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/wallclock"
 )
 
 // Section represents which section is currently active in the left column
@@ -34,6 +38,18 @@ const (
 	HelpView
 	AboutView
 	UploadDialogView
+	VideoHistoryView
+	JobsView
+	BatchView
+	TemplatePickerView
+	ExportDialogView
+	SetupView
+	ProfilePickerView
+	DeleteDialogView
+	SearchView
+	LogView
+	NoticesView
+	PaletteView
 )
 
 // Model represents the TUI application state
@@ -41,6 +57,10 @@ type Model struct {
 	// API and database
 	apiClient *api.Client
 	database  *db.DB
+	config    *config.Config
+	location  *time.Location // time zone of wall-clock times
+	keys      keyMap         // keys of the main view and dialogs, from the keybindings settings
+	help      help.Model     // renders the keys for the footer and the help screen
 
 	// UI state
 	width         int
@@ -49,23 +69,56 @@ type Model struct {
 	viewMode      ViewMode
 
 	// Components
-	spinner       spinner.Model
-	libraryList   list.Model
-	historyList   list.Model
-	detailsView   viewport.Model
-	questionInput textarea.Model
-	menuList      list.Model
+	spinner         spinner.Model
+	libraryList     list.Model
+	historyList     list.Model
+	detailsView     viewport.Model
+	questionInput   textarea.Model
+	menuList        list.Model
+	templateList    list.Model
+	exportList      list.Model
+	exportWallClock bool // export subtitles and chapters with wall-clock times
+	profileList     list.Model
+	setup           setupState
+	deletion        deleteState
+	search          searchState
+	palette         paletteState
 
 	// Data
 	videos        []models.Video
 	history       []models.QueryHistory
 	selectedVideo *models.Video
 	selectedQuery *models.QueryHistory
+	highlight     *searchHighlight // search result the selected video was opened from
+
+	// Per-video history
+	questionCounts   map[string]int        // number of saved queries per video ID
+	cachedQueries    map[int]bool          // queries served from the answer cache this session
+	historyScoped    bool                  // History section shows only the selected video
+	videoHistory     []models.QueryHistory // history of videoHistoryID, newest first
+	videoHistoryID   string
+	videoHistoryView viewport.Model
+
+	// Log viewer
+	logView viewport.Model
+
+	// Batch questions
+	markedVideos map[string]bool // videos selected for a batch question
+	batchAsk     bool            // the question dialog targets the selected videos
+	batch        *batchRun       // last batch question
+	nextBatchID  int
+	batchTable   table.Model
+
+	// Background jobs
+	jobs       *jobManager
+	jobsCursor int
 
 	// Status
+	usage         map[string]int // today's API calls per endpoint, nil until read
 	statusMessage string
 	isLoading     bool
-	err           error
+	notices       noticeCenter // errors and warnings of the session
+	output        *Output      // the terminal, for OSC 52 copies; nil when unknown
 
 	// Upload dialog state
 	uploadTitleInput textarea.Model
@@ -75,7 +128,9 @@ type Model struct {
 
 // videoItem implements list.Item for the library list
 type videoItem struct {
-	video models.Video
+	video     models.Video
+	questions int
+	marked    bool
 }
 
 func (v videoItem) Title() string {
@@ -83,22 +138,38 @@ func (v videoItem) Title() string {
 	if title == "" {
 		title = v.video.VideoID
 	}
+	if v.marked {
+		title = "✓ " + title
+	}
 	return title
 }
 
 func (v videoItem) Description() string {
 	status := strings.ToUpper(v.video.IndexingStatus)
 	duration := fmt.Sprintf("%.1fs", v.video.Metadata.Duration)
-	return fmt.Sprintf("%s • %s", status, duration)
+	questions := fmt.Sprintf("%d questions", v.questions)
+	if v.questions == 1 {
+		questions = "1 question"
+	}
+	return fmt.Sprintf("%s • %s • %s", status, duration, questions)
 }
 
+// FilterValue returns the text matched by the fuzzy filter: title, video ID,
+// description and indexing status
 func (v videoItem) FilterValue() string {
-	return v.video.Metadata.Title
+	return strings.Join([]string{
+		v.video.Metadata.Title,
+		v.video.VideoID,
+		v.video.Metadata.Description,
+		v.video.IndexingStatus,
+	}, " ")
 }
 
 // historyItem implements list.Item for the history list
 type historyItem struct {
-	query models.QueryHistory
+	query  models.QueryHistory
+	spans  []wallclock.Span // wall-clock spans of the clips, when the video start is known
+	cached bool             // served again from the answer cache
 }
 
 func (h historyItem) Title() string {
@@ -118,11 +189,19 @@ func (h historyItem) Description() string {
 		return "No content"
 	}
 	// Count sections or show truncated answer
-	return "Response available"
+	description := "Response available"
+	if h.cached {
+		description = "⚡ Cached"
+	}
+	if len(h.spans) > 0 {
+		return description + " • " + h.spans[0].String()
+	}
+	return description
 }
 
+// FilterValue returns the text matched by the fuzzy filter: question and answer
 func (h historyItem) FilterValue() string {
-	return h.query.Question
+	return h.query.Question + " " + h.query.Answer
 }
 
 // menuItem implements list.Item for the menu
@@ -136,34 +215,33 @@ func (m menuItem) Title() string       { return m.title }
 func (m menuItem) Description() string { return m.description }
 func (m menuItem) FilterValue() string { return m.title }
 
-// NewModel creates a new TUI model
-func NewModel(apiClient *api.Client, database *db.DB) Model {
+// NewModel creates a new TUI model from the effective configuration, the
+// built-in defaults when cfg is nil. A nil apiClient starts the API key setup.
+// The model owns database from then on; release it with Close.
+func NewModel(apiClient *api.Client, database *db.DB, cfg *config.Config) Model {
+	if cfg == nil {
+		cfg = config.Default()
+	}
 	// Initialize spinner
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 
 	// Initialize library list
 	libraryDelegate := list.NewDefaultDelegate()
 	libraryList := list.New([]list.Item{}, libraryDelegate, 0, 0)
 	libraryList.Title = "Videos"
 	libraryList.SetShowStatusBar(false)
-	libraryList.SetFilteringEnabled(false)
-	libraryList.Styles.Title = lipgloss.NewStyle().
-		Padding(0, 1).
-		Foreground(lipgloss.Color("230")).
-		Bold(true)
+	libraryList.SetFilteringEnabled(true)
+	libraryList.DisableQuitKeybindings()
 
 	// Initialize history list
 	historyDelegate := list.NewDefaultDelegate()
 	historyList := list.New([]list.Item{}, historyDelegate, 0, 0)
 	historyList.Title = "History"
 	historyList.SetShowStatusBar(false)
-	historyList.SetFilteringEnabled(false)
-	historyList.Styles.Title = lipgloss.NewStyle().
-		Padding(0, 1).
-		Foreground(lipgloss.Color("230")).
-		Bold(true)
+	historyList.SetFilteringEnabled(true)
+	historyList.DisableQuitKeybindings()
+	historyList.Filter = historyFilter(nil)
 
 	// Initialize details viewport
 	detailsView := viewport.New(0, 0)
@@ -179,10 +257,13 @@ func NewModel(apiClient *api.Client, database *db.DB) Model {
 	menuList.Title = "Menu"
 	menuList.SetShowStatusBar(false)
 	menuList.SetFilteringEnabled(false)
-	menuList.Styles.Title = lipgloss.NewStyle().
-		Padding(0, 1).
-		Foreground(lipgloss.Color("230")).
-		Bold(true)
+
+	// Initialize prompt template picker
+	templateDelegate := list.NewDefaultDelegate()
+	templateList := list.New([]list.Item{}, templateDelegate, 0, 0)
+	templateList.Title = "Prompt Templates"
+	templateList.SetShowStatusBar(false)
+	templateList.DisableQuitKeybindings()
 
 	// Initialize upload inputs
 	uploadTitleInput := textarea.New()
@@ -192,9 +273,17 @@ func NewModel(apiClient *api.Client, database *db.DB) Model {
 	uploadTitleInput.Focus()
 	uploadURLInput.Blur()
 
-	return Model{
+	location, err := cfg.Location()
+	if err != nil {
+		location = time.Local
+	}
+
+	m := Model{
 		apiClient:        apiClient,
 		database:         database,
+		config:           cfg,
+		location:         location,
+		keys:             newKeyMap(cfg.UI.Keybindings),
 		activeSection:    LibrarySection,
 		viewMode:         MainView,
 		spinner:          s,
@@ -203,21 +292,46 @@ func NewModel(apiClient *api.Client, database *db.DB) Model {
 		detailsView:      detailsView,
 		questionInput:    questionInput,
 		menuList:         menuList,
+		templateList:     templateList,
+		exportList:       newExportList(),
+		profileList:      newProfileList(),
+		search:           newSearchState(),
+		palette:          paletteState{input: newPaletteInput()},
 		videos:           []models.Video{},
 		history:          []models.QueryHistory{},
+		questionCounts:   map[string]int{},
+		cachedQueries:    map[int]bool{},
+		jobs:             newJobManager(cfg.JobLimit()),
+		markedVideos:     map[string]bool{},
+		batchTable:       table.New(table.WithFocused(true)),
+		videoHistoryView: viewport.New(0, 0),
+		logView:          viewport.New(0, 0),
 		statusMessage:    "Disconnected",
 		isLoading:        false,
 		uploadTitleInput: uploadTitleInput,
 		uploadURLInput:   uploadURLInput,
 		uploadFocus:      0,
 	}
+	m.restyle()
+	m.reportConfigWarnings()
+
+	// Without an API key, start with the setup
+	if apiClient == nil {
+		m.setup.startCmd = m.openSetup(true)
+	}
+	return m
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
+	// Without an API key, the setup screen runs first
+	if m.viewMode == SetupView {
+		return tea.Batch(m.spinner.Tick, m.loadUsage(), m.setup.startCmd)
+	}
 	return tea.Batch(
 		m.spinner.Tick,
 		m.loadHistory(),
 		m.testConnection(),
+		m.loadUsage(),
 	)
 }
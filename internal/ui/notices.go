@@ -0,0 +1,295 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/logging"
+)
+
+// maxNotices is how many errors and warnings the error center keeps
+const maxNotices = 200
+
+// noticeLevel is the severity of a notice
+type noticeLevel int
+
+const (
+	noticeWarning noticeLevel = iota
+	noticeError
+)
+
+// notice is an error or warning kept in the error center
+type notice struct {
+	time    time.Time
+	level   noticeLevel
+	context string // what was being done, e.g. "Error loading videos"
+	message string // the full message, redacted
+}
+
+// icon returns the marker of the notice level
+func (n notice) icon() string {
+	if n.level == noticeError {
+		return "✗"
+	}
+	return "⚠"
+}
+
+// String writes the notice on one line, as it is copied
+func (n notice) String() string {
+	return fmt.Sprintf("%s %s %s: %s", n.time.Format("2006-01-02 15:04:05"), n.icon(), n.context, n.message)
+}
+
+// noticeCenter keeps the errors and warnings of the session, oldest first
+type noticeCenter struct {
+	notices []notice
+	seen    int // notices there were when the error center was last opened
+	cursor  int
+	status  string // result of the last copy
+}
+
+// add keeps a notice, dropping the oldest past maxNotices
+func (c *noticeCenter) add(n notice) {
+	c.notices = append(c.notices, n)
+	if over := len(c.notices) - maxNotices; over > 0 {
+		c.notices = c.notices[over:]
+		c.seen = max(c.seen-over, 0)
+	}
+}
+
+// counts returns the number of errors and warnings
+func (c *noticeCenter) counts() (errors, warnings int) {
+	for _, n := range c.notices {
+		if n.level == noticeError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// reportError shows an error on the status line and keeps it, in full, in
+// the error center and the log
+func (m *Model) reportError(context string, err error) {
+	message := logging.Redact(err.Error())
+	m.statusMessage = fmt.Sprintf("%s: %s", context, firstLine(message))
+	m.notices.add(notice{time: time.Now(), level: noticeError, context: context, message: message})
+	slog.Error(context, "error", message)
+}
+
+// reportWarning keeps a warning in the error center and the log
+func (m *Model) reportWarning(context, message string) {
+	message = logging.Redact(message)
+	m.notices.add(notice{time: time.Now(), level: noticeWarning, context: context, message: message})
+	slog.Warn(context, "warning", message)
+}
+
+// reportConfigWarnings keeps the warnings of the configuration not kept
+// yet, e.g. after switching to a profile resolved from the same file
+func (m *Model) reportConfigWarnings() {
+	kept := make(map[string]bool)
+	for _, n := range m.notices.notices {
+		kept[n.message] = true
+	}
+	for _, warning := range m.config.Warnings() {
+		if !kept[logging.Redact(warning)] {
+			m.reportWarning("Configuration", warning)
+		}
+	}
+}
+
+// noticeBadge returns the error center line of the Status section, empty
+// when there is nothing to report
+func (m Model) noticeBadge() string {
+	errors, warnings := m.notices.counts()
+	if errors+warnings == 0 {
+		return ""
+	}
+
+	var parts []string
+	if errors > 0 {
+		parts = append(parts, plural(errors, "error"))
+	}
+	if warnings > 0 {
+		parts = append(parts, plural(warnings, "warning"))
+	}
+	badge := "⚠ " + strings.Join(parts, ", ")
+	if unseen := len(m.notices.notices) - m.notices.seen; unseen > 0 {
+		badge += fmt.Sprintf(" (%d new)", unseen)
+	}
+	return badge + ", " + m.keys.Errors.Help().Key + " to inspect"
+}
+
+// plural writes a count with its noun
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// openNotices shows the error center on the newest notice
+func (m *Model) openNotices() {
+	m.viewMode = NoticesView
+	m.notices.seen = len(m.notices.notices)
+	m.notices.cursor = max(len(m.notices.notices)-1, 0)
+	m.notices.status = ""
+}
+
+// writeClipboard copies text to the system clipboard
+var writeClipboard = clipboard.WriteAll
+
+// clipboardCopiedMsg is sent when a copy from the error center is done
+type clipboardCopiedMsg struct {
+	status string
+}
+
+// copyToClipboard copies text to the system clipboard, or through the
+// terminal (OSC 52) when there is no clipboard tool, e.g. over SSH. Clipboard
+// tools can be slow, so the copy runs outside Update.
+func (m Model) copyToClipboard(text string) tea.Cmd {
+	output := m.output
+	return func() tea.Msg {
+		if err := writeClipboard(text); err == nil {
+			return clipboardCopiedMsg{status: "Copied to the clipboard"}
+		}
+		if output == nil {
+			return clipboardCopiedMsg{status: "No clipboard available"}
+		}
+		output.Copy(text)
+		return clipboardCopiedMsg{status: "Sent to the terminal clipboard"}
+	}
+}
+
+// viewNotices renders the error center
+func (m Model) viewNotices() string {
+	var b strings.Builder
+
+	width := m.width - 16
+	if width < 30 {
+		width = 30
+	}
+	line := lipgloss.NewStyle().MaxWidth(width)
+
+	notices := m.notices.notices
+	if len(notices) == 0 {
+		b.WriteString("No errors or warnings.\n")
+	}
+
+	// Keep the selected notice in the visible window
+	rows := m.height/2 - 6
+	if rows < 3 {
+		rows = 3
+	}
+	start := 0
+	if m.notices.cursor >= rows {
+		start = m.notices.cursor - rows + 1
+	}
+	end := min(start+rows, len(notices))
+	for i := start; i < end; i++ {
+		n := notices[i]
+		text := line.Render(fmt.Sprintf("%s %s %s: %s", n.time.Format("15:04:05"), n.icon(), n.context, firstLine(n.message)))
+		if i == m.notices.cursor {
+			text = focusedStyle.Render(text)
+		}
+		b.WriteString(text + "\n")
+	}
+
+	if m.notices.cursor < len(notices) {
+		n := notices[m.notices.cursor]
+		b.WriteString("\n")
+		b.WriteString(titleStyle.Render(n.context))
+		b.WriteString(footerStyle.Render(" • " + n.time.Format("2006-01-02 15:04:05")))
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Width(width).Render(n.message))
+		b.WriteString("\n")
+	}
+	if m.notices.status != "" {
+		b.WriteString("\n" + statusStyle.Render(m.notices.status) + "\n")
+	}
+
+	errors, warnings := m.notices.counts()
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render(fmt.Sprintf("Errors and Warnings (%d, %d)", errors, warnings)),
+		"",
+		b.String(),
+		footerStyle.Render(joinHelp(m.keys.noticesHelp()...)),
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogStyle.Render(content),
+	)
+}
+
+// firstLine returns the first line of s
+func firstLine(s string) string {
+	first, _, _ := strings.Cut(s, "\n")
+	return first
+}
+
+// updateNotices handles input in the error center
+func (m Model) updateNotices(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, m.keys.Errors) {
+		m.viewMode = MainView
+		return m, nil
+	}
+
+	c := &m.notices
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.viewMode = MainView
+
+	case key.Matches(msg, m.keys.Up):
+		if c.cursor > 0 {
+			c.cursor--
+		}
+
+	case key.Matches(msg, m.keys.Down):
+		if c.cursor < len(c.notices)-1 {
+			c.cursor++
+		}
+
+	case key.Matches(msg, m.keys.CopyNotice):
+		if c.cursor < len(c.notices) {
+			c.status = "Copying..."
+			return m, m.copyToClipboard(c.notices[c.cursor].String())
+		}
+
+	case key.Matches(msg, m.keys.CopyNotices):
+		if len(c.notices) > 0 {
+			lines := make([]string, len(c.notices))
+			for i, n := range c.notices {
+				lines[i] = n.String()
+			}
+			c.status = "Copying..."
+			return m, m.copyToClipboard(strings.Join(lines, "\n"))
+		}
+
+	case key.Matches(msg, m.keys.Dismiss):
+		if c.cursor < len(c.notices) {
+			c.notices = append(c.notices[:c.cursor], c.notices[c.cursor+1:]...)
+			c.seen = len(c.notices)
+			if c.cursor >= len(c.notices) {
+				c.cursor = max(len(c.notices)-1, 0)
+			}
+		}
+
+	case key.Matches(msg, m.keys.ClearNotices):
+		c.notices = nil
+		c.seen = 0
+		c.cursor = 0
+		c.status = "Cleared"
+	}
+	return m, nil
+}
@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fboucher/be-my-eyes/internal/logging"
+)
+
+func TestNoticeCenterKeepsTheNewest(t *testing.T) {
+	var c noticeCenter
+	for i := 0; i < maxNotices; i++ {
+		c.add(notice{level: noticeError, message: fmt.Sprint(i)})
+	}
+	c.seen = 10
+	for i := 0; i < 5; i++ {
+		c.add(notice{level: noticeWarning, message: fmt.Sprint("new ", i)})
+	}
+
+	if len(c.notices) != maxNotices || c.notices[0].message != "5" || c.notices[maxNotices-1].message != "new 4" {
+		t.Fatalf("kept %d notices from %q to %q", len(c.notices), c.notices[0].message, c.notices[len(c.notices)-1].message)
+	}
+	if c.seen != 5 {
+		t.Errorf("seen = %d, want 5 once the 5 oldest are dropped", c.seen)
+	}
+	if errs, warnings := c.counts(); errs != maxNotices-5 || warnings != 5 {
+		t.Errorf("counts = %d errors, %d warnings", errs, warnings)
+	}
+}
+
+func TestReportErrorKeepsFullRedactedMessage(t *testing.T) {
+	logging.AddSecret("n0tice-t0ken")
+	m := NewModel(nil, nil, nil)
+
+	m.reportError("Error loading videos", errors.New("401 for n0tice-t0ken\nbody: details"))
+
+	if m.statusMessage != "Error loading videos: 401 for [REDACTED]" {
+		t.Errorf("status = %q, want the first line, redacted", m.statusMessage)
+	}
+	n := m.notices.notices[0]
+	if n.level != noticeError || n.message != "401 for [REDACTED]\nbody: details" {
+		t.Errorf("notice = %+v, want the full message, redacted", n)
+	}
+}
+
+func TestNoticeBadge(t *testing.T) {
+	m := NewModel(nil, nil, nil)
+	if badge := m.noticeBadge(); badge != "" {
+		t.Errorf("badge = %q, want none without notices", badge)
+	}
+
+	m.reportError("Error loading videos", errors.New("timeout"))
+	m.reportWarning("Configuration", "config.json is readable by other users")
+	m.reportWarning("Configuration", "another warning")
+	if badge := m.noticeBadge(); !strings.Contains(badge, "1 error, 2 warnings (3 new)") {
+		t.Errorf("badge = %q, want 1 error, 2 warnings, 3 new", badge)
+	}
+
+	m.openNotices()
+	if badge := m.noticeBadge(); strings.Contains(badge, "new") {
+		t.Errorf("badge = %q, want nothing new once inspected", badge)
+	}
+	m.reportWarning("Configuration", "one more")
+	if badge := m.noticeBadge(); !strings.Contains(badge, "3 warnings (1 new)") {
+		t.Errorf("badge = %q, want 1 new", badge)
+	}
+}
+
+func TestNoticesDismissAndClear(t *testing.T) {
+	m := NewModel(nil, nil, nil)
+	for _, message := range []string{"first", "second", "third"} {
+		m.reportWarning("Test", message)
+	}
+	m.openNotices()
+	press := func(k string) {
+		t.Helper()
+		updated, _ := m.updateNotices(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = updated.(Model)
+	}
+
+	// the error center opens on the newest notice
+	press("d")
+	if len(m.notices.notices) != 2 || m.notices.notices[1].message != "second" || m.notices.cursor != 1 {
+		t.Fatalf("after dismissing the newest: %+v, cursor %d", m.notices.notices, m.notices.cursor)
+	}
+	press("k")
+	press("d")
+	if len(m.notices.notices) != 1 || m.notices.notices[0].message != "second" || m.notices.cursor != 0 {
+		t.Fatalf("after dismissing the oldest: %+v, cursor %d", m.notices.notices, m.notices.cursor)
+	}
+
+	press("X")
+	if len(m.notices.notices) != 0 || m.noticeBadge() != "" {
+		t.Errorf("notices = %+v after clearing, want none", m.notices.notices)
+	}
+	press("d") // nothing left to dismiss
+}
+
+func TestNoticeString(t *testing.T) {
+	n := notice{time: time.Date(2026, 3, 14, 9, 5, 0, 0, time.UTC), level: noticeWarning, context: "Configuration", message: "readable"}
+	if got := n.String(); got != "2026-03-14 09:05:00 ⚠ Configuration: readable" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestCopyNoticeRunsAsCommand(t *testing.T) {
+	saved := writeClipboard
+	t.Cleanup(func() { writeClipboard = saved })
+	writeClipboard = func(string) error { return errors.New("no clipboard tool") }
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "terminal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	m := NewModel(nil, nil, nil).WithOutput(NewOutput(f))
+	m.reportWarning("Test", "copy me")
+	m.openNotices()
+
+	updated, cmd := m.updateNotices(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("copying should return a command")
+	}
+	if data, _ := os.ReadFile(f.Name()); len(data) != 0 {
+		t.Fatalf("the terminal was written to during Update: %q", data)
+	}
+
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+	if m.notices.status != "Sent to the terminal clipboard" {
+		t.Errorf("status = %q, want the terminal clipboard", m.notices.status)
+	}
+	data, _ := os.ReadFile(f.Name())
+	if !strings.HasPrefix(string(data), "\x1b]52;c;") {
+		t.Errorf("terminal got %q, want an OSC 52 copy", data)
+	}
+
+	writeClipboard = func(string) error { return nil }
+	_, cmd = m.updateNotices(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+	if msg := cmd().(clipboardCopiedMsg); msg.status != "Copied to the clipboard" {
+		t.Errorf("status = %q, want the system clipboard", msg.status)
+	}
+}
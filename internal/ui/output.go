@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"os"
+	"sync"
+
+	"github.com/muesli/termenv"
+)
+
+// Output is the terminal the program draws on. Writes are serialized, so a
+// sequence sent outside the view, such as an OSC 52 copy, never lands in the
+// middle of a frame. It stays a file so the program still finds the terminal.
+type Output struct {
+	*os.File
+	mu sync.Mutex
+}
+
+// NewOutput wraps the terminal the program writes to, usually os.Stdout
+func NewOutput(f *os.File) *Output {
+	return &Output{File: f}
+}
+
+// Write writes p in one piece
+func (o *Output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.File.Write(p)
+}
+
+// WriteString writes s in one piece
+func (o *Output) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
+
+// Copy sends text to the clipboard of the terminal (OSC 52)
+func (o *Output) Copy(text string) {
+	termenv.NewOutput(o, termenv.WithProfile(termenv.Ascii)).Copy(text)
+}
+
+// WithOutput returns the model drawing on output, which copies go through
+// when there is no clipboard tool
+func (m Model) WithOutput(output *Output) Model {
+	m.output = output
+	return m
+}
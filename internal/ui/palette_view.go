@@ -0,0 +1,190 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// paletteState is the command palette: a query and the commands matching it
+type paletteState struct {
+	input   textinput.Model
+	matches []int // indexes in commands, best match first
+	cursor  int
+	status  string // why the chosen command can't run
+}
+
+// newPaletteInput creates the query input of the command palette
+func newPaletteInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "Type a command, e.g. export"
+	input.Prompt = "> "
+	input.Width = 50
+	return input
+}
+
+// openPalette shows the command palette with every command
+func (m *Model) openPalette() tea.Cmd {
+	m.viewMode = PaletteView
+	m.palette.input.Reset()
+	m.palette.status = ""
+	m.filterPalette()
+	return m.palette.input.Focus()
+}
+
+// filterPalette fuzzy matches the commands against the query, on their
+// title and description
+func (m *Model) filterPalette() {
+	m.palette.cursor = 0
+	m.palette.matches = m.palette.matches[:0]
+
+	query := strings.TrimSpace(m.palette.input.Value())
+	if query == "" {
+		for i := range commands {
+			m.palette.matches = append(m.palette.matches, i)
+		}
+		return
+	}
+
+	targets := make([]string, len(commands))
+	for i, c := range commands {
+		targets[i] = c.title + " " + c.describe(*m)
+	}
+	for _, rank := range list.DefaultFilter(query, targets) {
+		m.palette.matches = append(m.palette.matches, rank.Index)
+	}
+}
+
+// viewPalette renders the command palette
+func (m Model) viewPalette() string {
+	var b strings.Builder
+
+	width := m.width - 16
+	if width > 80 {
+		width = 80
+	}
+	if width < 30 {
+		width = 30
+	}
+
+	// Keep the selected command in the visible window
+	rows := m.height - 14
+	if rows < 3 {
+		rows = 3
+	}
+	start := 0
+	if m.palette.cursor >= rows {
+		start = m.palette.cursor - rows + 1
+	}
+	end := min(start+rows, len(m.palette.matches))
+
+	if len(m.palette.matches) == 0 {
+		b.WriteString(footerStyle.Render("No matching command") + "\n")
+	}
+	for i := start; i < end; i++ {
+		c := commands[m.palette.matches[i]]
+		key := c.key(m)
+		title := c.title
+		detail := c.describe(m)
+		reason := c.reason(m)
+		if reason != "" {
+			detail = reason
+		}
+
+		// Title and description on the left, the key on the right
+		left := fmt.Sprintf("%s - %s", title, detail)
+		left = lipgloss.NewStyle().MaxWidth(width - len(key) - 4).Render(left)
+		gap := width - lipgloss.Width(left) - lipgloss.Width(key) - 2
+		if gap < 1 {
+			gap = 1
+		}
+		line := left + strings.Repeat(" ", gap) + key
+
+		switch {
+		case i == m.palette.cursor && reason != "":
+			line = footerStyle.Render("> " + line)
+		case i == m.palette.cursor:
+			line = focusedStyle.Render("> " + line)
+		case reason != "":
+			line = footerStyle.Render("  " + line)
+		default:
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if m.palette.status != "" {
+		b.WriteString("\n" + statusStyle.Render(m.palette.status) + "\n")
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render("Commands"),
+		"",
+		m.palette.input.View(),
+		"",
+		b.String(),
+		footerStyle.Render("type to search, ↑↓: select, enter: run, esc: close"),
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogStyle.Render(content),
+	)
+}
+
+// updatePalette handles input in the command palette
+func (m Model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// The palette key closes it, unless it would be typed into the query
+	if key.Matches(msg, m.keys.Palette) && len(msg.Runes) == 0 {
+		m.viewMode = MainView
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.viewMode = MainView
+		return m, nil
+
+	case "up", "ctrl+k":
+		if m.palette.cursor > 0 {
+			m.palette.cursor--
+		}
+		return m, nil
+
+	case "down", "ctrl+j", "ctrl+n":
+		if m.palette.cursor < len(m.palette.matches)-1 {
+			m.palette.cursor++
+		}
+		return m, nil
+
+	case "enter":
+		if m.palette.cursor >= len(m.palette.matches) {
+			return m, nil
+		}
+		c := commands[m.palette.matches[m.palette.cursor]]
+		if reason := c.reason(m); reason != "" {
+			m.palette.status = fmt.Sprintf("%s isn't available: %s", c.title, reason)
+			return m, nil
+		}
+		m.palette.input.Blur()
+		return m, m.runCommand(c.action)
+	}
+
+	var cmd tea.Cmd
+	previous := m.palette.input.Value()
+	m.palette.input, cmd = m.palette.input.Update(msg)
+	if m.palette.input.Value() != previous {
+		m.palette.status = ""
+		m.filterPalette()
+	}
+	return m, cmd
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+// commandByAction returns the command running action
+func commandByAction(t *testing.T, action string) command {
+	t.Helper()
+	for _, c := range commands {
+		if c.action == action {
+			return c
+		}
+	}
+	t.Fatalf("no %s command", action)
+	return command{}
+}
+
+// typePalette types text into the palette query
+func typePalette(m Model, text string) Model {
+	for _, r := range text {
+		updated, _ := m.updatePalette(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(Model)
+	}
+	return m
+}
+
+func TestPaletteFilter(t *testing.T) {
+	m := NewModel(nil, nil, nil)
+	m.openPalette()
+	if len(m.palette.matches) != len(commands) {
+		t.Fatalf("an empty query matches %d commands, want all %d", len(m.palette.matches), len(commands))
+	}
+
+	m = typePalette(m, "exprt")
+	if len(m.palette.matches) == 0 || commands[m.palette.matches[0]].action != "export" {
+		t.Fatalf("exprt should match export first, got %v", m.palette.matches)
+	}
+
+	// descriptions match too
+	m.openPalette()
+	m = typePalette(m, "subtitles")
+	if len(m.palette.matches) != 1 || commands[m.palette.matches[0]].action != "export" {
+		t.Errorf("subtitles should only match export, got %v", m.palette.matches)
+	}
+
+	m.openPalette()
+	m = typePalette(m, "zzzz")
+	if len(m.palette.matches) != 0 || !strings.Contains(m.viewPalette(), "No matching command") {
+		t.Errorf("zzzz should match nothing, got %v", m.palette.matches)
+	}
+}
+
+func TestCommandAvailability(t *testing.T) {
+	video := &models.Video{VideoID: "v1"}
+	withClips := &models.QueryHistory{VideoClips: []models.VideoClip{{StartTime: 1, EndTime: 2}}}
+
+	tests := []struct {
+		name    string
+		action  string
+		setup   func(m *Model)
+		enabled bool
+	}{
+		{"ask without a video", "ask", func(m *Model) {}, false},
+		{"ask with a video", "ask", func(m *Model) { m.selectedVideo = video }, true},
+		{"delete from History", "delete", func(m *Model) { m.selectedVideo = video; m.activeSection = HistorySection }, false},
+		{"delete from Videos", "delete", func(m *Model) { m.selectedVideo = video; m.activeSection = LibrarySection }, true},
+		{"export without clips", "export", func(m *Model) {
+			m.activeSection = HistorySection
+			m.selectedQuery = &models.QueryHistory{}
+		}, false},
+		{"export with clips", "export", func(m *Model) { m.activeSection = HistorySection; m.selectedQuery = withClips }, true},
+		{"batch ask without selection", "batch-ask", func(m *Model) {}, false},
+		{"batch ask with selection", "batch-ask", func(m *Model) { m.markedVideos["v1"] = true }, true},
+		{"batch results before any batch", "batch-results", func(m *Model) {}, false},
+		{"filter in Status", "filter", func(m *Model) { m.activeSection = StatusSection }, false},
+		{"filter in History", "filter", func(m *Model) { m.activeSection = HistorySection }, true},
+		{"refresh", "refresh", func(m *Model) {}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(nil, nil, nil)
+			tt.setup(&m)
+			reason := commandByAction(t, tt.action).reason(m)
+			if (reason == "") != tt.enabled {
+				t.Errorf("reason = %q, want enabled %v", reason, tt.enabled)
+			}
+		})
+	}
+}
+
+func TestPaletteRefusesUnavailableCommand(t *testing.T) {
+	m := NewModel(nil, nil, nil)
+	m.openPalette()
+	m = typePalette(m, "delete video")
+	if len(m.palette.matches) == 0 || commands[m.palette.matches[0]].action != "delete" {
+		t.Fatalf("delete video should match delete first, got %v", m.palette.matches)
+	}
+
+	updated, cmd := m.updatePalette(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.viewMode != PaletteView || cmd != nil {
+		t.Errorf("an unavailable command should keep the palette open")
+	}
+	if !strings.Contains(m.palette.status, "Delete Video isn't available: select a video in Videos") {
+		t.Errorf("status = %q", m.palette.status)
+	}
+
+	// its key is shown next to it
+	if key := commandByAction(t, "mark").key(m); key != "space" {
+		t.Errorf("mark key = %q, want space", key)
+	}
+}
@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/config"
+	"github.com/fboucher/be-my-eyes/internal/db"
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+// profileSwitchedMsg is sent when the backends of another profile are ready
+type profileSwitchedMsg struct {
+	config    *config.Config
+	apiClient *api.Client // nil when the profile has no API key yet
+	database  *db.DB
+	err       error
+}
+
+// profileItem implements list.Item for the profile picker
+type profileItem struct {
+	name    string
+	profile *config.ProfileConfig // nil for the default profile
+	active  bool
+}
+
+func (p profileItem) Title() string {
+	if p.active {
+		return "✓ " + p.name
+	}
+	return p.name
+}
+
+func (p profileItem) Description() string {
+	if p.profile == nil {
+		return "Top-level settings of the config file"
+	}
+	key := "no API key yet"
+	switch {
+	case p.profile.APIKeyCommand != "":
+		key = "key from api_key_command"
+	case p.profile.APIKey != "":
+		key = "saved API key"
+	}
+	if p.profile.API != nil && p.profile.API.BaseURL != "" {
+		return key + " • " + p.profile.API.BaseURL
+	}
+	return key
+}
+
+func (p profileItem) FilterValue() string { return p.name }
+
+// newProfileList creates the profile picker
+func newProfileList() list.Model {
+	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	l.Title = "Profiles"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+	return l
+}
+
+// openProfilePicker lists the profiles of the config file to switch to
+func (m *Model) openProfilePicker() tea.Cmd {
+	names := m.config.ProfileNames()
+	if len(names) == 0 {
+		m.viewMode = MainView
+		m.statusMessage = "No profiles in the config file, add them under \"profiles\""
+		return nil
+	}
+
+	active := m.config.ActiveProfile()
+	items := []list.Item{profileItem{name: config.DefaultProfile, active: active == config.DefaultProfile}}
+	for _, name := range names {
+		items = append(items, profileItem{name: name, profile: m.config.Profiles[name], active: name == active})
+	}
+	m.viewMode = ProfilePickerView
+	return m.profileList.SetItems(items)
+}
+
+// switchProfile resolves the configuration of a profile, then gets its API
+// key and opens its history database. The current backends stay in use
+// until the new ones are ready.
+func (m Model) switchProfile(name string) tea.Cmd {
+	current := m.config
+	return func() tea.Msg {
+		cfg, err := current.WithProfile(name)
+		if err != nil {
+			return profileSwitchedMsg{err: err}
+		}
+
+		var client *api.Client
+		apiKey, err := config.EnsureAPIKey(cfg)
+		switch {
+		case err == nil:
+			if client, err = cfg.NewClient(apiKey); err != nil {
+				return profileSwitchedMsg{err: err}
+			}
+		case !errors.Is(err, config.ErrNoAPIKey):
+			return profileSwitchedMsg{err: err}
+		}
+
+		if _, err := config.MigrateDB(cfg); err != nil {
+			return profileSwitchedMsg{err: err}
+		}
+		database, err := db.Open(cfg.Storage.DBPath)
+		if err != nil {
+			return profileSwitchedMsg{err: fmt.Errorf("failed to open the history of profile %s: %w", name, err)}
+		}
+		if client != nil {
+			client.SetLedger(database)
+		}
+		return profileSwitchedMsg{config: cfg, apiClient: client, database: database}
+	}
+}
+
+// applyProfile replaces the backends with those of the new profile and
+// clears everything loaded from the previous one
+func (m *Model) applyProfile(msg profileSwitchedMsg) tea.Cmd {
+	if m.database != nil {
+		m.database.Close()
+	}
+	m.apiClient = msg.apiClient
+	m.database = msg.database
+	m.config = msg.config
+	if location, err := msg.config.Location(); err == nil {
+		m.location = location
+	}
+
+	m.videos = []models.Video{}
+	m.history = []models.QueryHistory{}
+	m.selectedVideo = nil
+	m.selectedQuery = nil
+	m.questionCounts = map[string]int{}
+	m.cachedQueries = map[int]bool{}
+	m.markedVideos = map[string]bool{}
+	m.historyScoped = false
+	m.videoHistory = nil
+	m.videoHistoryID = ""
+	m.batch = nil
+	m.highlight = nil
+	m.usage = nil
+	m.search.current = nil
+	m.isLoading = false
+	m.reportConfigWarnings()
+	cmds := []tea.Cmd{m.updateLibraryList(), m.updateHistoryList()}
+
+	if m.apiClient == nil {
+		m.statusMessage = "Profile " + m.config.ActiveProfile() + " needs an API key"
+		cmds = append(cmds, m.openSetup(true))
+		return tea.Batch(cmds...)
+	}
+	m.viewMode = MainView
+	m.statusMessage = "Switched to profile " + m.config.ActiveProfile()
+	return tea.Batch(append(cmds, m.loadHistory(), m.testConnection())...)
+}
+
+// Close closes the history database in use, which changes when switching
+// profiles
+func (m Model) Close() error {
+	if m.database == nil {
+		return nil
+	}
+	return m.database.Close()
+}
+
+// viewProfilePicker renders the profile picker
+func (m Model) viewProfilePicker() string {
+	m.profileList.SetSize(70, 18)
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.profileList.View(),
+		footerStyle.Render("enter: switch profile, esc: cancel"),
+	)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialogStyle.Render(content))
+}
+
+// updateProfilePicker handles input in the profile picker
+func (m Model) updateProfilePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.viewMode = MainView
+		return m, nil
+
+	case "enter":
+		item, ok := m.profileList.SelectedItem().(profileItem)
+		m.viewMode = MainView
+		if !ok || item.active {
+			return m, nil
+		}
+		// Jobs write to the current history database
+		if running := m.jobs.count(JobQueued) + m.jobs.count(JobRunning); running > 0 {
+			m.statusMessage = fmt.Sprintf("Wait for the %d background job(s) to finish before switching profiles", running)
+			return m, nil
+		}
+		m.isLoading = true
+		m.statusMessage = fmt.Sprintf("Switching to profile %s...", item.name)
+		return m, m.switchProfile(item.name)
+	}
+
+	var cmd tea.Cmd
+	m.profileList, cmd = m.profileList.Update(msg)
+	return m, cmd
+}
+
+// profileLabel returns the active profile for the Status section
+func (m Model) profileLabel() string {
+	return "Profile: " + m.config.ActiveProfile()
+}
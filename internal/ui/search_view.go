@@ -0,0 +1,302 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/qa"
+	"github.com/fboucher/be-my-eyes/internal/wallclock"
+)
+
+// recentSearches is how many saved searches the Search view lists
+const recentSearches = 20
+
+// searchState is the Search view: a query, then the segments it found
+type searchState struct {
+	input     textinput.Model
+	list      list.Model
+	listFocus bool // the results have the keyboard, not the query
+	running   bool
+	current   *models.SearchHistory // search whose results are listed, nil for the saved searches
+	err       error
+}
+
+// searchHighlight is the search result a video was opened from, shown in
+// its details
+type searchHighlight struct {
+	query  string
+	result models.SearchResult
+}
+
+// searchDoneMsg is sent when a search has been run and saved
+type searchDoneMsg struct {
+	search *models.SearchHistory
+	err    error
+}
+
+// searchesLoadedMsg is sent when the saved searches are loaded
+type searchesLoadedMsg struct {
+	searches []models.SearchHistory
+	err      error
+}
+
+// searchResultItem implements list.Item for a segment found by a search
+type searchResultItem struct {
+	result models.SearchResult
+	title  string
+	span   string // wall-clock span, when the video start is known
+}
+
+func (s searchResultItem) Title() string { return s.title }
+
+func (s searchResultItem) Description() string {
+	parts := []string{fmt.Sprintf("%.1fs - %.1fs", s.result.StartTime, s.result.EndTime)}
+	if s.span != "" {
+		parts = append(parts, s.span)
+	}
+	parts = append(parts, fmt.Sprintf("score %.2f", s.result.Score))
+	return strings.Join(parts, " • ")
+}
+
+func (s searchResultItem) FilterValue() string { return s.title + " " + s.result.Caption }
+
+// savedSearchItem implements list.Item for a past search
+type savedSearchItem struct {
+	search models.SearchHistory
+}
+
+func (s savedSearchItem) Title() string { return "🔍 " + s.search.Query }
+
+func (s savedSearchItem) Description() string {
+	return fmt.Sprintf("%s • %d results", s.search.CreatedAt.Local().Format("2006-01-02 15:04"), len(s.search.Results))
+}
+
+func (s savedSearchItem) FilterValue() string { return s.search.Query }
+
+// newSearchState creates the Search view components
+func newSearchState() searchState {
+	input := textinput.New()
+	input.Placeholder = "Describe what to find, e.g. a person opening a door"
+	input.Width = 60
+
+	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	l.Title = "Recent Searches"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+
+	return searchState{input: input, list: l}
+}
+
+// openSearch shows the Search view with the saved searches
+func (m *Model) openSearch() tea.Cmd {
+	m.viewMode = SearchView
+	m.search.listFocus = false
+	m.search.err = nil
+	if m.search.current == nil {
+		return tea.Batch(m.search.input.Focus(), m.loadSearches())
+	}
+	return m.search.input.Focus()
+}
+
+// loadSearches loads the saved searches from the database
+func (m Model) loadSearches() tea.Cmd {
+	return func() tea.Msg {
+		searches, err := m.database.GetSearches(recentSearches)
+		return searchesLoadedMsg{searches: searches, err: err}
+	}
+}
+
+// runSearch searches the library and saves the search with its results
+func (m Model) runSearch(query string) tea.Cmd {
+	return func() tea.Msg {
+		response, err := m.apiClient.Search(query, 0)
+		if err != nil {
+			return searchDoneMsg{err: err}
+		}
+		search, err := m.database.SaveSearch(query, response.Results)
+		return searchDoneMsg{search: search, err: err}
+	}
+}
+
+// showSearchResults lists the results of a search
+func (m *Model) showSearchResults(search *models.SearchHistory) tea.Cmd {
+	starts := wallclock.Starts(m.videos, m.location)
+	items := make([]list.Item, len(search.Results))
+	for i, r := range search.Results {
+		item := searchResultItem{result: r, title: qa.VideoTitle(m.libraryVideo(r.VideoID))}
+		if start, ok := starts[r.VideoID]; ok {
+			item.span = wallclock.ClipSpan(start, models.VideoClip{StartTime: r.StartTime, EndTime: r.EndTime}).String()
+		}
+		items[i] = item
+	}
+
+	m.search.current = search
+	m.search.list.Title = fmt.Sprintf("Results for %q (%d)", search.Query, len(search.Results))
+	m.search.list.ResetSelected()
+	if len(items) > 0 {
+		m.search.listFocus = true
+		m.search.input.Blur()
+	}
+	return m.search.list.SetItems(items)
+}
+
+// showSavedSearches lists the saved searches
+func (m *Model) showSavedSearches(searches []models.SearchHistory) tea.Cmd {
+	items := make([]list.Item, len(searches))
+	for i, s := range searches {
+		items[i] = savedSearchItem{search: s}
+	}
+	m.search.current = nil
+	m.search.list.Title = "Recent Searches"
+	m.search.list.ResetSelected()
+	return m.search.list.SetItems(items)
+}
+
+// jumpToResult selects the video of a search result in Videos and shows
+// the matching segment in its details
+func (m *Model) jumpToResult(r models.SearchResult) {
+	index := -1
+	for i, v := range m.videos {
+		if v.VideoID == r.VideoID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		m.search.err = fmt.Errorf("video %s is not in the library anymore", r.VideoID)
+		return
+	}
+
+	m.libraryList.ResetFilter()
+	m.libraryList.Select(index)
+	m.updateSelectedVideo()
+	query := ""
+	if m.search.current != nil {
+		query = m.search.current.Query
+	}
+	m.highlight = &searchHighlight{query: query, result: r}
+	m.activeSection = LibrarySection
+	m.viewMode = MainView
+	m.updateDetailView()
+}
+
+// renderSearchMatch renders the search result a video was opened from
+func (m Model) renderSearchMatch(v *models.Video) string {
+	if m.highlight == nil || m.highlight.result.VideoID != v.VideoID {
+		return ""
+	}
+	r := m.highlight.result
+	clip := models.VideoClip{StartTime: r.StartTime, EndTime: r.EndTime}
+
+	when := fmt.Sprintf("%.1fs - %.1fs", r.StartTime, r.EndTime)
+	if start, ok := wallclock.Start(v.Metadata, m.location); ok {
+		when = fmt.Sprintf("%s (%s)", wallclock.ClipSpan(start, clip), when)
+	}
+
+	var b strings.Builder
+	b.WriteString(focusedStyle.Render(fmt.Sprintf("▶ Search match for %q", m.highlight.query)))
+	b.WriteString("\n")
+	b.WriteString(focusedStyle.Render(fmt.Sprintf("  %s • score %.2f", when, r.Score)))
+	b.WriteString("\n")
+	if r.Caption != "" {
+		b.WriteString("  " + r.Caption + "\n")
+	}
+	return b.String() + "\n"
+}
+
+// viewSearch renders the Search view
+func (m Model) viewSearch() string {
+	m.search.list.SetSize(m.width-10, m.height-14)
+
+	var status string
+	switch {
+	case m.search.running:
+		status = m.spinner.View() + " Searching..."
+	case m.search.err != nil:
+		status = fmt.Sprintf("❌ %v", m.search.err)
+	case m.search.current != nil && len(m.search.current.Results) == 0:
+		status = "No matching segments"
+	}
+
+	footer := "enter: search, tab: results, esc: close"
+	if m.search.listFocus {
+		footer = "enter: open, tab: edit query, esc: close"
+		if m.search.current != nil {
+			footer = "enter: open the video at this segment, backspace: recent searches, tab: edit query, esc: close"
+		}
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render("Search Videos"),
+		"",
+		m.search.input.View(),
+		status,
+		"",
+		m.search.list.View(),
+		footerStyle.Render(footer),
+	)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialogStyle.Render(content))
+}
+
+// updateSearch handles input in the Search view
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.viewMode = MainView
+		return m, nil
+
+	case "tab":
+		m.search.listFocus = !m.search.listFocus
+		if m.search.listFocus {
+			m.search.input.Blur()
+			return m, nil
+		}
+		return m, m.search.input.Focus()
+	}
+
+	if !m.search.listFocus {
+		if msg.String() == "enter" {
+			query := strings.TrimSpace(m.search.input.Value())
+			if query == "" || m.search.running {
+				return m, nil
+			}
+			m.search.running = true
+			m.search.err = nil
+			return m, m.runSearch(query)
+		}
+		var cmd tea.Cmd
+		m.search.input, cmd = m.search.input.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "enter":
+		switch item := m.search.list.SelectedItem().(type) {
+		case searchResultItem:
+			m.jumpToResult(item.result)
+			return m, nil
+		case savedSearchItem:
+			search := item.search
+			m.search.input.SetValue(search.Query)
+			return m, m.showSearchResults(&search)
+		}
+		return m, nil
+
+	case "backspace":
+		if m.search.current != nil {
+			return m, m.loadSearches()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.search.list, cmd = m.search.list.Update(msg)
+	return m, cmd
+}
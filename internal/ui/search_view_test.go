@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fboucher/be-my-eyes/internal/logging"
+)
+
+func TestSearchErrorWithoutClientIsRedacted(t *testing.T) {
+	logging.AddSecret("secret-key-42")
+	m := NewModel(nil, nil, nil)
+	m.search.running = true
+
+	updated, _ := m.Update(searchDoneMsg{err: errors.New("401: bad key secret-key-42")})
+	m = updated.(Model)
+
+	if m.search.running || m.search.err == nil {
+		t.Fatalf("search = %+v, want a stopped search with an error", m.search)
+	}
+	if strings.Contains(m.search.err.Error(), "secret-key-42") {
+		t.Errorf("err = %q, leaks the key", m.search.err)
+	}
+}
@@ -0,0 +1,354 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/config"
+)
+
+// setupStep is a screen of the API key setup
+type setupStep int
+
+const (
+	setupKeyStep setupStep = iota
+	setupValidatingStep
+	setupPrefsStep
+)
+
+// accentPresets are the accent colors offered during setup, over the
+// accent of the theme
+var accentPresets = []struct {
+	name  string
+	color string
+}{
+	{"From the theme", ""},
+	{"Pink", "205"},
+	{"Blue", "69"},
+	{"Green", "42"},
+	{"Orange", "214"},
+}
+
+// setupState is the API key setup flow, shown on first run and from the
+// "Change API key" menu entry
+type setupState struct {
+	step        setupStep
+	firstRun    bool // no key yet: leaving the setup quits
+	keyInput    textinput.Model
+	key         string // validated key
+	err         error
+	prefCursor  int // 0: theme, 1: accent, 2: upload indexing
+	theme       string
+	accent      int // index in accentPresets
+	uploadIndex bool
+	startTheme  string  // theme when the setup opened, saved only if changed
+	startAccent int     // accent when the setup opened, saved only if changed
+	startCmd    tea.Cmd // focuses the key input of a setup opened before Init
+}
+
+// apiKeyValidatedMsg is sent when a key has been tried against the API
+type apiKeyValidatedMsg struct {
+	key string
+	err error
+}
+
+// apiKeySavedMsg is sent when the setup has been written to the config file
+type apiKeySavedMsg struct {
+	err error
+}
+
+// openSetup starts the API key setup
+func (m *Model) openSetup(firstRun bool) tea.Cmd {
+	input := textinput.New()
+	input.Placeholder = "Reka API key"
+	input.EchoMode = textinput.EchoPassword
+	input.EchoCharacter = '•'
+	input.Width = 50
+
+	accent := 0
+	for i, preset := range accentPresets {
+		if preset.color == m.config.UI.Theme.Accent {
+			accent = i
+		}
+	}
+
+	m.setup = setupState{
+		firstRun:    firstRun,
+		keyInput:    input,
+		theme:       m.config.UI.Theme.Name,
+		accent:      accent,
+		uploadIndex: *m.config.Defaults.UploadIndex,
+		startTheme:  m.config.UI.Theme.Name,
+		startAccent: accent,
+	}
+	m.viewMode = SetupView
+	return m.setup.keyInput.Focus()
+}
+
+// validateAPIKey tries a key with a GetVideos call
+func (m Model) validateAPIKey(key string) tea.Cmd {
+	cfg := m.config
+	return func() tea.Msg {
+		client, err := cfg.NewClient(key)
+		if err != nil {
+			return apiKeyValidatedMsg{key: key, err: err}
+		}
+		_, err = client.GetVideos([]string{})
+		return apiKeyValidatedMsg{key: key, err: err}
+	}
+}
+
+// saveSetup writes the key and preferences to the config file. The theme
+// and accent are only written when changed, so one picked by NO_COLOR or a
+// custom accent isn't saved in their place.
+func (m Model) saveSetup() tea.Cmd {
+	setup := m.setup
+	profile := m.config.ActiveProfile()
+	return func() tea.Msg {
+		file, err := config.Load()
+		if err != nil {
+			return apiKeySavedMsg{err: err}
+		}
+
+		file.SetAPIKey(profile, setup.key)
+		if setup.theme != setup.startTheme || setup.accent != setup.startAccent {
+			if file.UI == nil {
+				file.UI = &config.UIConfig{}
+			}
+			if file.UI.Theme == nil {
+				file.UI.Theme = &config.ThemeConfig{}
+			}
+		}
+		if setup.theme != setup.startTheme {
+			file.UI.Theme.Name = setup.theme
+		}
+		if setup.accent != setup.startAccent {
+			file.UI.Theme.Accent = accentPresets[setup.accent].color
+		}
+		if file.Defaults == nil {
+			file.Defaults = &config.DefaultsConfig{}
+		}
+		index := setup.uploadIndex
+		file.Defaults.UploadIndex = &index
+
+		return apiKeySavedMsg{err: file.Save()}
+	}
+}
+
+// keyOverride returns what provides the API key over the one saved in the
+// config file, empty when the saved key is used
+func (m Model) keyOverride() string {
+	source := m.config.Source("api_key")
+	switch {
+	case strings.HasPrefix(source, "env "):
+		return strings.TrimPrefix(source, "env ")
+	case source == config.SourceFlag:
+		return "--set api_key"
+	case m.config.APIKeyCommand != "":
+		return "api_key_command"
+	}
+	return ""
+}
+
+// finishSetup applies a saved setup and starts using the new key, unless
+// another key takes precedence over the saved one
+func (m *Model) finishSetup() tea.Cmd {
+	if m.setup.theme != m.setup.startTheme {
+		m.config.UI.Theme.Name = m.setup.theme
+	}
+	if m.setup.accent != m.setup.startAccent {
+		m.config.UI.Theme.Accent = accentPresets[m.setup.accent].color
+	}
+	index := m.setup.uploadIndex
+	m.config.Defaults.UploadIndex = &index
+	m.restyle()
+
+	if override := m.keyOverride(); override != "" && m.apiClient != nil {
+		m.viewMode = MainView
+		m.statusMessage = fmt.Sprintf("API key saved; %s still takes precedence", override)
+		return nil
+	}
+
+	m.config.APIKey = m.setup.key
+	client, err := m.config.NewClient(m.setup.key)
+	if err != nil {
+		m.setup.err = err
+		return nil
+	}
+	m.apiClient = client
+	if m.database != nil {
+		m.apiClient.SetLedger(m.database)
+	}
+	m.viewMode = MainView
+
+	if m.setup.firstRun {
+		m.statusMessage = "API key saved"
+		return tea.Batch(m.loadHistory(), m.testConnection())
+	}
+	m.statusMessage = "API key changed"
+	m.isLoading = true
+	return tea.Batch(m.testConnection(), m.refreshLibrary())
+}
+
+// viewSetup renders the API key setup
+func (m Model) viewSetup() string {
+	var b strings.Builder
+
+	title := "Change API key"
+	if m.setup.firstRun {
+		title = "Welcome to Be My Eyes"
+	}
+	b.WriteString(titleStyle.Render(title))
+	b.WriteString("\n\n")
+
+	var footer string
+	switch m.setup.step {
+	case setupKeyStep, setupValidatingStep:
+		if m.setup.firstRun {
+			if profile := m.config.ActiveProfile(); profile != config.DefaultProfile {
+				b.WriteString(fmt.Sprintf("Profile %s has no Reka API key yet. Get one at https://link.reka.ai/free\n", profile))
+			} else {
+				b.WriteString("No Reka API key was found. Get one at https://link.reka.ai/free\n")
+			}
+		}
+		b.WriteString("Enter your API key:\n\n")
+		b.WriteString(m.setup.keyInput.View())
+		b.WriteString("\n\n")
+
+		// A saved key is overridden by these
+		if override := m.keyOverride(); override != "" {
+			b.WriteString(footerStyle.Render(fmt.Sprintf("Note: %s is set and takes precedence over the saved key.", override)))
+			b.WriteString("\n\n")
+		}
+
+		if m.setup.step == setupValidatingStep {
+			b.WriteString(m.spinner.View() + " Checking the key...")
+		} else if m.setup.err != nil {
+			b.WriteString(fmt.Sprintf("❌ %v", m.setup.err))
+		}
+
+		footer = "enter: check the key, esc: cancel"
+		if m.setup.firstRun {
+			footer = "enter: check the key, esc: quit"
+		}
+
+	case setupPrefsStep:
+		b.WriteString("✓ The key works. A few preferences:\n\n")
+
+		rows := []string{
+			fmt.Sprintf("Theme:           ◂ %s ▸", m.setup.theme),
+			fmt.Sprintf("Accent color:    ◂ %s ▸", accentPresets[m.setup.accent].name),
+			fmt.Sprintf("Index uploads:   [%s]", map[bool]string{true: "x", false: " "}[m.setup.uploadIndex]),
+		}
+		for i, row := range rows {
+			if i == m.setup.prefCursor {
+				row = focusedStyle.Render("> " + row)
+			} else {
+				row = "  " + row
+			}
+			b.WriteString(row + "\n")
+		}
+		b.WriteString("\n")
+		b.WriteString(m.setupPreview())
+
+		if m.setup.err != nil {
+			b.WriteString(fmt.Sprintf("\n\n❌ %v", m.setup.err))
+		}
+		footer = "↑↓: select, ←→/space: change, enter: save, esc: back"
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, b.String(), "", footerStyle.Render(footer))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialogStyle.Render(content))
+}
+
+// updateSetup handles input in the API key setup
+func (m Model) updateSetup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.setup.step {
+	case setupValidatingStep:
+		return m, nil
+
+	case setupPrefsStep:
+		switch msg.String() {
+		case "esc":
+			m.setup.step = setupKeyStep
+			m.setup.err = nil
+			return m, m.setup.keyInput.Focus()
+		case "up", "k":
+			m.setup.prefCursor = (m.setup.prefCursor + 2) % 3
+		case "down", "j", "tab":
+			m.setup.prefCursor = (m.setup.prefCursor + 1) % 3
+		case "left", "h", "right", "l", " ":
+			back := msg.String() == "left" || msg.String() == "h"
+			switch m.setup.prefCursor {
+			case 0:
+				names := m.config.ThemeNames()
+				step := 1
+				if back {
+					step = len(names) - 1
+				}
+				for i, name := range names {
+					if name == m.setup.theme {
+						m.setup.theme = names[(i+step)%len(names)]
+						break
+					}
+				}
+			case 1:
+				step := 1
+				if back {
+					step = len(accentPresets) - 1
+				}
+				m.setup.accent = (m.setup.accent + step) % len(accentPresets)
+			default:
+				m.setup.uploadIndex = !m.setup.uploadIndex
+			}
+		case "enter":
+			return m, m.saveSetup()
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		if m.setup.firstRun {
+			return m, tea.Quit
+		}
+		m.viewMode = MainView
+		return m, nil
+
+	case "enter":
+		key := strings.TrimSpace(m.setup.keyInput.Value())
+		if key == "" {
+			m.setup.err = fmt.Errorf("the API key is empty")
+			return m, nil
+		}
+		m.setup.err = nil
+		m.setup.step = setupValidatingStep
+		return m, m.validateAPIKey(key)
+	}
+
+	var cmd tea.Cmd
+	m.setup.keyInput, cmd = m.setup.keyInput.Update(msg)
+	return m, cmd
+}
+
+// setupPreview shows the colors of the chosen theme and accent
+func (m Model) setupPreview() string {
+	preview := *m.config
+	preview.UI = &config.UIConfig{
+		Theme:  &config.ThemeConfig{Name: m.setup.theme, Accent: accentPresets[m.setup.accent].color},
+		Themes: m.config.UI.Themes,
+	}
+	theme := preview.Theme()
+	sample := func(c, text string) string {
+		return lipgloss.NewStyle().Foreground(color(c)).Render(text)
+	}
+	return strings.Join([]string{
+		sample(theme.Accent, "████ accent"),
+		sample(theme.Title, "████ title"),
+		sample(theme.Focus, "████ selection"),
+		sample(theme.Muted, "████ hints"),
+	}, "  ")
+}
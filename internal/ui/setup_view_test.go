@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fboucher/be-my-eyes/internal/api"
+	"github.com/fboucher/be-my-eyes/internal/config"
+)
+
+// resolveConfig resolves the configuration from a config file in a
+// temporary directory and the given environment
+func resolveConfig(t *testing.T, file string, env map[string]string) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, "BME_") || name == "REKA_API_KEY" || name == "NO_COLOR" {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+	t.Setenv("XDG_DATA_HOME", dir)
+	t.Setenv("XDG_STATE_HOME", dir)
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(file), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BME_CONFIG", path)
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+
+	cfg, err := config.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestNewModelFocusesSetupKey(t *testing.T) {
+	m := NewModel(nil, nil, nil)
+	if m.viewMode != SetupView {
+		t.Fatalf("viewMode = %v, want the setup", m.viewMode)
+	}
+	if !m.setup.keyInput.Focused() || m.setup.startCmd == nil {
+		t.Error("the key input should be focused, with its command run by Init")
+	}
+}
+
+func TestFinishSetupKeepsOverridingKey(t *testing.T) {
+	cfg := resolveConfig(t, `{}`, map[string]string{"REKA_API_KEY": "env-key"})
+	client := api.NewClient("env-key")
+	m := NewModel(client, nil, cfg)
+
+	m.openSetup(false)
+	m.setup.key = "typed-key"
+	m.finishSetup()
+
+	if m.apiClient != client || m.config.APIKey != "env-key" {
+		t.Errorf("the REKA_API_KEY key should stay in use, got %q", m.config.APIKey)
+	}
+	if m.viewMode != MainView || !strings.Contains(m.statusMessage, "REKA_API_KEY") {
+		t.Errorf("status = %q, want a note about REKA_API_KEY", m.statusMessage)
+	}
+}
+
+func TestFinishSetupUsesNewKey(t *testing.T) {
+	cfg := resolveConfig(t, `{"api_key":"old-key"}`, nil)
+	client := api.NewClient("old-key")
+	m := NewModel(client, nil, cfg)
+
+	m.openSetup(false)
+	m.setup.key = "typed-key"
+	m.finishSetup()
+
+	if m.apiClient == client || m.config.APIKey != "typed-key" {
+		t.Errorf("the typed key should be used, got %q", m.config.APIKey)
+	}
+}
+
+func TestSaveSetupKeepsUnchangedTheme(t *testing.T) {
+	cfg := resolveConfig(t, `{"ui":{"theme":{"accent":"#123456"}}}`, map[string]string{"NO_COLOR": "1"})
+	m := NewModel(nil, nil, cfg)
+
+	m.openSetup(false)
+	m.setup.key = "typed-key"
+	if msg := m.saveSetup()().(apiKeySavedMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	file, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.UI.Theme.Name != "" || file.UI.Theme.Accent != "#123456" {
+		t.Errorf("saved theme = %+v, want it untouched", *file.UI.Theme)
+	}
+
+	// a theme picked during the setup is saved
+	m.setup.theme = config.ThemeLight
+	m.setup.accent = (m.setup.startAccent + 1) % len(accentPresets)
+	if msg := m.saveSetup()().(apiKeySavedMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	if file, err = config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if file.UI.Theme.Name != config.ThemeLight || file.UI.Theme.Accent != accentPresets[m.setup.accent].color {
+		t.Errorf("saved theme = %+v, want light with the new accent", *file.UI.Theme)
+	}
+}
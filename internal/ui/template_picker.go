@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/prompts"
+)
+
+// templatesLoadedMsg is sent when the prompt library is loaded
+type templatesLoadedMsg struct {
+	templates []models.PromptTemplate
+	err       error
+}
+
+// templateItem implements list.Item for the template picker
+type templateItem struct {
+	template models.PromptTemplate
+}
+
+func (t templateItem) Title() string {
+	if t.template.BuiltIn {
+		return t.template.Name + " (built-in)"
+	}
+	return t.template.Name
+}
+func (t templateItem) Description() string { return t.template.Text }
+func (t templateItem) FilterValue() string { return t.template.Name + " " + t.template.Text }
+
+// loadTemplates loads the prompt library
+func (m Model) loadTemplates() tea.Cmd {
+	return func() tea.Msg {
+		templates, err := prompts.List(m.database)
+		return templatesLoadedMsg{templates: templates, err: err}
+	}
+}
+
+// openTemplatePicker shows the prompt library on top of the question dialog
+func (m *Model) openTemplatePicker() tea.Cmd {
+	m.viewMode = TemplatePickerView
+	return m.loadTemplates()
+}
+
+// insertTemplate adds the selected template to the question. Single questions
+// get it rendered for the selected video; batch questions keep the template
+// so it is rendered for each video.
+func (m *Model) insertTemplate(t models.PromptTemplate) {
+	m.viewMode = QuestionDialogView
+
+	text := t.Text
+	if !m.batchAsk && m.selectedVideo != nil {
+		rendered, err := prompts.Render(t, *m.selectedVideo)
+		if err != nil {
+			m.reportError("Error", err)
+			return
+		}
+		text = rendered
+	}
+	m.questionInput.InsertString(text)
+	m.questionInput.Focus()
+}
+
+// viewTemplatePicker renders the prompt library picker
+func (m Model) viewTemplatePicker() string {
+	m.templateList.SetSize(60, 15)
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.templateList.View(),
+		footerStyle.Render("enter: insert, /: filter, esc: back"),
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogStyle.Render(content),
+	)
+}
+
+// updateTemplatePicker handles input in the prompt library picker
+func (m Model) updateTemplatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.templateList.SettingFilter() {
+		switch msg.String() {
+		case "esc":
+			if m.templateList.FilterState() == list.Unfiltered {
+				m.viewMode = QuestionDialogView
+				return m, nil
+			}
+		case "enter":
+			if item, ok := m.templateList.SelectedItem().(templateItem); ok {
+				m.insertTemplate(item.template)
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.templateList, cmd = m.templateList.Update(msg)
+	return m, routeTemplateListCmd(cmd)
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+// settle runs the commands returned by Update and feeds their messages back,
+// the way Bubble Tea does, skipping commands that wait (e.g. cursor blinks)
+func settle(t *testing.T, m Model, cmd tea.Cmd) Model {
+	t.Helper()
+	queue := []tea.Cmd{cmd}
+	for steps := 0; len(queue) > 0 && steps < 100; steps++ {
+		c := queue[0]
+		queue = queue[1:]
+		if c == nil {
+			continue
+		}
+
+		msgs := make(chan tea.Msg, 1)
+		go func() { msgs <- c() }()
+		var msg tea.Msg
+		select {
+		case msg = <-msgs:
+		case <-time.After(50 * time.Millisecond):
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case nil:
+		case tea.BatchMsg:
+			queue = append(queue, msg...)
+		default:
+			next, cmd := m.Update(msg)
+			m = next.(Model)
+			queue = append(queue, cmd)
+		}
+	}
+	return m
+}
+
+// typeKeys sends each rune of s as a key press
+func typeKeys(t *testing.T, m Model, s string) Model {
+	t.Helper()
+	for _, r := range s {
+		next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = settle(t, next.(Model), cmd)
+	}
+	return m
+}
+
+func TestTemplatePickerFilter(t *testing.T) {
+	m := NewModel(nil, nil, nil)
+	m.viewMode = TemplatePickerView
+	m.templateList.SetSize(60, 15)
+
+	next, cmd := m.Update(templatesLoadedMsg{templates: []models.PromptTemplate{
+		{Name: "summarize", Text: "Summarize this video"},
+		{Name: "scenes", Text: "Describe each scene"},
+		{Name: "on-screen-text", Text: "List the on-screen text"},
+	}})
+	m = settle(t, next.(Model), cmd)
+
+	m = typeKeys(t, m, "/summ")
+	if got := len(m.templateList.VisibleItems()); got != 1 {
+		t.Fatalf("visible templates = %d, want 1 matching the filter", got)
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter}) // apply the filter
+	m = next.(Model)
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter}) // insert the template
+	m = next.(Model)
+	if m.viewMode != QuestionDialogView || m.questionInput.Value() != "Summarize this video" {
+		t.Errorf("view = %v, question = %q, want the template inserted", m.viewMode, m.questionInput.Value())
+	}
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/config"
+)
+
+// terminalProfile is the color profile of the terminal, read when the
+// program starts so it is kept before a theme without colors turns them off
+var terminalProfile = lipgloss.ColorProfile()
+
+// color returns a theme color, none when it is empty
+func color(c string) lipgloss.TerminalColor {
+	if c == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(c)
+}
+
+// themeSavedMsg is sent when the chosen theme is written to the config file
+type themeSavedMsg struct {
+	name string
+	err  error
+}
+
+// restyle applies the theme of the configuration to the shared styles and
+// to the components that have their own
+func (m *Model) restyle() {
+	theme := m.config.Theme()
+	applyTheme(theme)
+
+	m.spinner.Style = lipgloss.NewStyle().Foreground(color(theme.Accent))
+	m.help = newHelp(theme)
+	for _, l := range []*list.Model{
+		&m.libraryList, &m.historyList, &m.menuList, &m.templateList,
+		&m.exportList, &m.profileList, &m.search.list,
+	} {
+		l.Styles.Title = lipgloss.NewStyle().
+			Padding(0, 1).
+			Foreground(color(theme.Title)).
+			Bold(true)
+		l.SetDelegate(newListDelegate(theme))
+	}
+
+	styles := table.DefaultStyles()
+	styles.Header = styles.Header.BorderForeground(color(theme.Border)).Bold(true)
+	styles.Selected = styles.Selected.Foreground(color(theme.Focus))
+	m.batchTable.SetStyles(styles)
+}
+
+// newListDelegate creates the item renderer of the lists, the selected
+// item in the accent color
+func newListDelegate(theme config.ThemeConfig) list.DefaultDelegate {
+	d := list.NewDefaultDelegate()
+	d.Styles.SelectedTitle = d.Styles.SelectedTitle.
+		Foreground(color(theme.Accent)).
+		BorderForeground(color(theme.Accent))
+	d.Styles.SelectedDesc = d.Styles.SelectedDesc.
+		Foreground(color(theme.Accent)).
+		BorderForeground(color(theme.Accent))
+	d.Styles.FilterMatch = d.Styles.FilterMatch.Foreground(color(theme.Focus))
+	return d
+}
+
+// nextTheme returns the theme after the current one, built-in themes first
+func (m Model) nextTheme() string {
+	names := m.config.ThemeNames()
+	for i, name := range names {
+		if name == m.config.UI.Theme.Name {
+			return names[(i+1)%len(names)]
+		}
+	}
+	return names[0]
+}
+
+// switchTheme applies the next theme and saves it as ui.theme.name
+func (m *Model) switchTheme() tea.Cmd {
+	name := m.nextTheme()
+	m.config.UI.Theme.Name = name
+	m.restyle()
+	m.statusMessage = "Theme: " + name
+
+	return func() tea.Msg {
+		file, err := config.Load()
+		if err != nil {
+			return themeSavedMsg{name: name, err: err}
+		}
+		if file.UI == nil {
+			file.UI = &config.UIConfig{}
+		}
+		if file.UI.Theme == nil {
+			file.UI.Theme = &config.ThemeConfig{}
+		}
+		file.UI.Theme.Name = name
+		return themeSavedMsg{name: name, err: file.Save()}
+	}
+}
@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/config"
+	"github.com/muesli/termenv"
+)
+
+func TestApplyThemeRestoresTerminalColors(t *testing.T) {
+	saved := terminalProfile
+	t.Cleanup(func() {
+		terminalProfile = saved
+		lipgloss.SetColorProfile(saved)
+	})
+	terminalProfile = termenv.TrueColor
+
+	cfg := resolveConfig(t, `{"ui":{"theme":{"name":"monochrome"}}}`, nil)
+	applyTheme(cfg.Theme())
+	if got := lipgloss.ColorProfile(); got != termenv.Ascii {
+		t.Fatalf("profile = %v with monochrome, want Ascii", got)
+	}
+
+	cfg.UI.Theme.Name = config.ThemeDark
+	applyTheme(cfg.Theme())
+	if got := lipgloss.ColorProfile(); got != termenv.TrueColor {
+		t.Errorf("profile = %v after monochrome, want the terminal's TrueColor", got)
+	}
+}
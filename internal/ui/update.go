@@ -1,24 +1,26 @@
 package ui
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fboucher/be-my-eyes/internal/logging"
 	"github.com/fboucher/be-my-eyes/internal/models"
+	"github.com/fboucher/be-my-eyes/internal/qa"
 )
 
-// uploadVideo calls the external API to upload a video
+// uploadVideo queues a video upload as a background job
 func (m Model) uploadVideo(title, url string) tea.Cmd {
-	return func() tea.Msg {
-		_, err := m.apiClient.UploadVideo(title, url, true)
-		if err != nil {
-			return videosLoadedMsg{videos: nil, err: err}
-		}
-		return videosLoadedMsg{videos: nil, err: nil}
-	}
+	_, cmd := m.jobs.add("upload", "Upload: "+title, func(ctx context.Context) (tea.Msg, error) {
+		_, err := m.apiClient.WithContext(ctx).UploadVideo(title, url, *m.config.Defaults.UploadIndex)
+		return videoUploadedMsg{title: title, err: err}, err
+	})
+	return cmd
 }
 
 // ...existing code...
@@ -31,16 +33,33 @@ type historyLoadedMsg struct {
 	err     error
 }
 
+// videoHistoryLoadedMsg is sent when the history of a single video is loaded
+type videoHistoryLoadedMsg struct {
+	videoID string
+	history []models.QueryHistory
+	err     error
+}
+
 // videosLoadedMsg is sent when videos are loaded from API
 type videosLoadedMsg struct {
 	videos []models.Video
 	err    error
 }
 
-// questionAskedMsg is sent when a question is asked
+// questionAskedMsg is sent when a question job completes
 type questionAskedMsg struct {
-	response *models.QAResponse
-	err      error
+	batchID    int
+	videoID    string
+	videoTitle string
+	question   string
+	query      *models.QueryHistory
+	err        error
+}
+
+// videoUploadedMsg is sent when an upload job completes
+type videoUploadedMsg struct {
+	title string
+	err   error
 }
 
 // connectionTestedMsg is sent when connection test completes
@@ -57,6 +76,14 @@ func (m Model) loadHistory() tea.Cmd {
 	}
 }
 
+// loadVideoHistory loads the history of a single video from the database
+func (m Model) loadVideoHistory(videoID string) tea.Cmd {
+	return func() tea.Msg {
+		history, err := m.database.GetHistoryByVideoID(videoID)
+		return videoHistoryLoadedMsg{videoID: videoID, history: history, err: err}
+	}
+}
+
 // testConnection tests the API connection
 func (m Model) testConnection() tea.Cmd {
 	return func() tea.Msg {
@@ -79,77 +106,40 @@ func (m Model) refreshLibrary() tea.Cmd {
 	}
 }
 
-// askQuestion asks a question about the current video
-func (m Model) askQuestion(question string) tea.Cmd {
+// askQuestion queues a question about the current video as a background job
+func (m Model) askQuestion(question string, refresh bool) tea.Cmd {
 	if m.selectedVideo == nil {
 		return nil
 	}
+	_, cmd := m.askVideo(*m.selectedVideo, question, 0, refresh)
+	return cmd
+}
 
-	videoID := m.selectedVideo.VideoID
-	videoTitle := m.selectedVideo.Metadata.Title
-	if videoTitle == "" {
-		videoTitle = videoID
-	}
-
-	return func() tea.Msg {
-		response, err := m.apiClient.AskQuestion(videoID, question)
-		if err != nil {
-			return questionAskedMsg{response: nil, err: err}
-		}
-
-		// Parse the chat_response JSON string to extract structured data
-		// The chat_response field contains an escaped JSON string
-		var chatData struct {
-			Sections []struct {
-				SectionID   string                   `json:"section_id"`
-				SectionType string                   `json:"section_type"`
-				Markdown    string                   `json:"markdown,omitempty"`
-				VideoClips  []map[string]interface{} `json:"video_clips,omitempty"`
-			} `json:"sections"`
-		}
-
-		// Default answer is the raw response in case parsing fails
-		globalAnswer := response.ChatResponse
-		var videoClips []models.VideoClip
-
-		// Try to parse the chat_response as JSON
-		if err := json.Unmarshal([]byte(response.ChatResponse), &chatData); err == nil {
-			// Successfully parsed, now extract the data
-			for _, section := range chatData.Sections {
-				if section.SectionType == "markdown" && section.SectionID == "1" {
-					// This is the global answer
-					globalAnswer = section.Markdown
-				} else if section.SectionType == "video-clips-info" {
-					// Extract video clips
-					for _, clipMap := range section.VideoClips {
-						clip := models.VideoClip{}
-
-						if clipID, ok := clipMap["video_clip_id"].(string); ok {
-							clip.ClipID = clipID
-						}
-						if startTime, ok := clipMap["video_clip_start_time"].(float64); ok {
-							clip.StartTime = startTime
-						}
-						if endTime, ok := clipMap["video_clip_end_time"].(float64); ok {
-							clip.EndTime = endTime
-						}
-						if info, ok := clipMap["video_clip_info"].(string); ok {
-							clip.Info = info
-						}
-
-						videoClips = append(videoClips, clip)
-					}
-				}
-			}
-		}
-
-		// Save to database with video title, parsed answer, and video clips
-		if err := m.database.SaveQuery(videoID, videoTitle, question, globalAnswer, videoClips, response.Error, response.Status); err != nil {
-			return questionAskedMsg{response: response, err: err}
+// askVideo queues a question about a video as a background job and returns
+// the job ID. batchID ties the answer to a batch question, 0 for a single
+// question. A cached answer is reused unless refresh is set.
+func (m Model) askVideo(video models.Video, question string, batchID int, refresh bool) (int, tea.Cmd) {
+	videoID := video.VideoID
+	videoTitle := qa.VideoTitle(video)
+
+	label := fmt.Sprintf("%s: %s", videoTitle, question)
+	job, cmd := m.jobs.add("ask", label, func(ctx context.Context) (tea.Msg, error) {
+		opts := qa.Options{CacheTTL: m.config.AnswerCacheTTL(), Refresh: refresh}
+		query, err := qa.AskVideo(ctx, m.apiClient, m.database, video, question, opts)
+		if ctx.Err() != nil {
+			// Cancelled: nothing to report
+			return nil, ctx.Err()
 		}
-
-		return questionAskedMsg{response: response, err: nil}
-	}
+		return questionAskedMsg{
+			batchID:    batchID,
+			videoID:    videoID,
+			videoTitle: videoTitle,
+			question:   question,
+			query:      query,
+			err:        err,
+		}, err
+	})
+	return job.ID, cmd
 }
 
 // Update handles messages and updates the model
@@ -182,6 +172,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateHelpView(msg)
 		case AboutView:
 			return m.updateAboutView(msg)
+		case VideoHistoryView:
+			return m.updateVideoHistoryView(msg)
+		case JobsView:
+			return m.updateJobsView(msg)
+		case BatchView:
+			return m.updateBatchView(msg)
+		case TemplatePickerView:
+			return m.updateTemplatePicker(msg)
+		case ExportDialogView:
+			return m.updateExportDialog(msg)
+		case SetupView:
+			return m.updateSetup(msg)
+		case ProfilePickerView:
+			return m.updateProfilePicker(msg)
+		case DeleteDialogView:
+			return m.updateDeleteDialog(msg)
+		case LogView:
+			return m.updateLogView(msg)
+		case NoticesView:
+			return m.updateNotices(msg)
+		case PaletteView:
+			return m.updatePalette(msg)
+		case SearchView:
+			return m.updateSearch(msg)
 		}
 
 	case tea.MouseMsg:
@@ -194,13 +208,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.spinner, cmd = m.spinner.Update(msg)
 		cmds = append(cmds, cmd)
 
+	case listMsg:
+		cmds = append(cmds, m.updateSectionList(msg.section, msg.msg))
+
 	case historyLoadedMsg:
 		if msg.err != nil {
-			m.err = msg.err
-			m.statusMessage = fmt.Sprintf("Error loading history: %v", msg.err)
+			m.reportError("Error loading history", msg.err)
 		} else {
 			m.history = msg.history
-			m.updateHistoryList()
+			m.countQuestions()
+			cmds = append(cmds, m.updateHistoryList(), m.updateLibraryList())
 
 			// Automatically load library from API on startup
 			m.isLoading = true
@@ -208,27 +225,171 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, m.refreshLibrary())
 		}
 
+	case videoHistoryLoadedMsg:
+		// Ignore results for a video that is no longer the one being shown
+		if msg.videoID != m.videoHistoryID {
+			break
+		}
+		if msg.err != nil {
+			m.reportError("Error loading video history", msg.err)
+		} else {
+			m.videoHistory = msg.history
+			cmds = append(cmds, m.updateHistoryList())
+			m.refreshVideoHistoryView()
+		}
+
 	case videosLoadedMsg:
 		m.isLoading = false
 		if msg.err != nil {
-			m.err = msg.err
-			m.statusMessage = fmt.Sprintf("Error loading videos: %v", msg.err)
+			m.reportError("Error loading videos", msg.err)
 		} else {
 			m.videos = msg.videos
-			m.updateLibraryList()
+			// The history shows wall-clock times from the video start times
+			cmds = append(cmds, m.updateLibraryList(), m.updateHistoryList())
 			m.statusMessage = "Connected"
 		}
 
+	case jobFinishedMsg:
+		result, cmd := m.jobs.finish(msg)
+		cmds = append(cmds, cmd)
+		if result != nil {
+			cmds = append(cmds, func() tea.Msg { return result })
+		}
+
 	case questionAskedMsg:
-		m.isLoading = false
-		m.viewMode = MainView
+		m.recordBatchResult(msg)
 		if msg.err != nil {
-			m.err = msg.err
-			m.statusMessage = fmt.Sprintf("Error asking question: %v", msg.err)
+			m.reportError("Error asking question", msg.err)
+		} else if msg.query != nil && msg.query.Cached {
+			m.cachedQueries[msg.query.ID] = true
+			m.statusMessage = fmt.Sprintf("Cached answer (%s), %s in the question dialog asks again", msg.videoTitle, m.keys.SubmitRefresh.Help().Key)
+			if msg.batchID == 0 {
+				cmds = append(cmds, m.showQuery(msg.query.ID))
+			} else {
+				cmds = append(cmds, m.updateHistoryList())
+			}
 		} else {
-			m.statusMessage = "Question answered"
+			m.statusMessage = fmt.Sprintf("Question answered (%s)", msg.videoTitle)
 			// Reload history
 			cmds = append(cmds, m.loadHistory())
+			if m.videoHistoryID != "" {
+				cmds = append(cmds, m.loadVideoHistory(m.videoHistoryID))
+			}
+		}
+
+	case templatesLoadedMsg:
+		if msg.err != nil {
+			m.reportError("Error loading templates", msg.err)
+			m.viewMode = QuestionDialogView
+		} else {
+			items := make([]list.Item, len(msg.templates))
+			for i, t := range msg.templates {
+				items[i] = templateItem{template: t}
+			}
+			cmds = append(cmds, routeTemplateListCmd(m.templateList.SetItems(items)))
+		}
+
+	case templateListMsg:
+		var cmd tea.Cmd
+		m.templateList, cmd = m.templateList.Update(msg.msg)
+		cmds = append(cmds, routeTemplateListCmd(cmd))
+
+	case apiKeyValidatedMsg:
+		if msg.err != nil {
+			m.setup.step = setupKeyStep
+			// The rejected key isn't the client's; don't echo it either
+			m.setup.err = fmt.Errorf("the key was rejected: %s", strings.ReplaceAll(msg.err.Error(), msg.key, "[REDACTED]"))
+			cmds = append(cmds, m.setup.keyInput.Focus())
+		} else {
+			m.setup.key = msg.key
+			m.setup.err = nil
+			m.setup.step = setupPrefsStep
+		}
+
+	case apiKeySavedMsg:
+		if msg.err != nil {
+			m.setup.err = fmt.Errorf("failed to save the configuration: %w", msg.err)
+		} else {
+			cmds = append(cmds, m.finishSetup())
+		}
+
+	case profileSwitchedMsg:
+		m.isLoading = false
+		if msg.err != nil {
+			m.reportError("Error switching profile", msg.err)
+		} else {
+			cmds = append(cmds, m.applyProfile(msg))
+		}
+
+	case themeSavedMsg:
+		if msg.err != nil {
+			m.reportError("Error saving theme "+msg.name, msg.err)
+		} else {
+			m.statusMessage = fmt.Sprintf("Theme: %s (saved)", msg.name)
+		}
+
+	case clipboardCopiedMsg:
+		m.notices.status = msg.status
+
+	case exportedMsg:
+		if msg.err != nil {
+			m.reportError("Error exporting clips", msg.err)
+		} else {
+			m.statusMessage = fmt.Sprintf("Exported %d clips to %s", msg.clips, msg.path)
+		}
+
+	case videoUploadedMsg:
+		if msg.err != nil {
+			m.reportError("Error uploading video", msg.err)
+		} else {
+			m.isLoading = true
+			m.statusMessage = fmt.Sprintf("Uploaded %s, refreshing...", msg.title)
+			cmds = append(cmds, m.refreshLibrary())
+		}
+
+	case usageLoadedMsg:
+		if msg.err == nil {
+			m.usage = msg.calls
+		}
+		cmds = append(cmds, usageTick())
+
+	case usageTickMsg:
+		cmds = append(cmds, m.loadUsage())
+
+	case searchDoneMsg:
+		m.search.running = false
+		if msg.err != nil {
+			m.search.err = fmt.Errorf("search failed: %s", logging.Redact(msg.err.Error()))
+			m.reportError("Error searching videos", m.search.err)
+		} else {
+			cmds = append(cmds, m.showSearchResults(msg.search))
+		}
+
+	case searchesLoadedMsg:
+		if msg.err != nil {
+			m.search.err = msg.err
+			m.reportError("Error loading searches", msg.err)
+		} else {
+			cmds = append(cmds, m.showSavedSearches(msg.searches))
+		}
+
+	case videoDeletedMsg:
+		if msg.err != nil {
+			m.reportError("Error deleting video", msg.err)
+			break
+		}
+		m.forgetVideo(msg.videoID)
+		m.isLoading = true
+		if msg.purged > 0 {
+			m.statusMessage = fmt.Sprintf("Deleted %s and %d saved questions", msg.title, msg.purged)
+			// Reloading the history refreshes the library too
+			cmds = append(cmds, m.loadHistory())
+			if m.videoHistoryID == msg.videoID {
+				cmds = append(cmds, m.loadVideoHistory(msg.videoID))
+			}
+		} else {
+			m.statusMessage = fmt.Sprintf("Deleted %s", msg.title)
+			cmds = append(cmds, m.refreshLibrary())
 		}
 
 	case connectionTestedMsg:
@@ -237,10 +398,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// If we have history with video IDs, the library will be loaded automatically
 			// when historyLoadedMsg is processed
 		} else {
-			m.statusMessage = "Disconnected"
 			if msg.err != nil {
-				m.err = msg.err
+				m.reportError("Connection failed", msg.err)
 			}
+			m.statusMessage = "Disconnected"
 		}
 	}
 
@@ -251,22 +412,44 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) updateMainView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
-	switch msg.String() {
-	case "q":
+	// While a filter is being typed, every key belongs to the list
+	if m.activeListSettingFilter() {
+		cmds = append(cmds, m.updateSectionList(m.activeSection, msg))
+		if msg.String() == "tab" {
+			// The list applies the filter on tab; keep it and move on
+			m.activeSection = (m.activeSection + 1) % 3
+			m.updateDetailView()
+		}
+		return m, tea.Batch(cmds...)
+	}
+
+	k := m.keys
+	switch {
+	case key.Matches(msg, k.Quit):
 		return m, tea.Quit
 
-	case "tab":
-		// Switch active section
+	case key.Matches(msg, k.Switch):
+		// Switch active section (each list keeps its own filter)
 		m.activeSection = (m.activeSection + 1) % 3
 		m.updateDetailView()
 
-	case "r":
+	case key.Matches(msg, k.Filter):
+		// Start fuzzy filtering the active list, whatever key is bound
+		cmds = append(cmds, m.updateSectionList(m.activeSection, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")}))
+
+	case key.Matches(msg, k.ClearFilter):
+		// Clear the filter of the active list
+		if m.activeListFiltered() {
+			cmds = append(cmds, m.updateSectionList(m.activeSection, msg))
+		}
+
+	case key.Matches(msg, k.Refresh):
 		// Refresh library
 		m.isLoading = true
 		m.statusMessage = "Refreshing..."
 		cmds = append(cmds, m.refreshLibrary())
 
-	case "a":
+	case key.Matches(msg, k.Ask):
 		// Ask question
 		if m.selectedVideo != nil {
 			m.viewMode = QuestionDialogView
@@ -274,25 +457,70 @@ func (m Model) updateMainView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.questionInput.Focus()
 		}
 
-	case "x":
+	case key.Matches(msg, k.Scope):
+		// Toggle History between all videos and the selected video
+		cmds = append(cmds, m.toggleHistoryScope())
+
+	case key.Matches(msg, k.VideoHistory):
+		// Open the Q&A timeline of the selected video
+		cmds = append(cmds, m.openVideoHistory())
+
+	case key.Matches(msg, k.Mark):
+		// Select the video for a batch question
+		if m.activeSection == LibrarySection {
+			cmds = append(cmds, m.toggleMark())
+		}
+
+	case key.Matches(msg, k.BatchAsk):
+		// Ask the same question about every selected video
+		m.openBatchAsk()
+
+	case key.Matches(msg, k.Batch):
+		// Compare the answers of the last batch question
+		m.viewMode = BatchView
+
+	case key.Matches(msg, k.Export):
+		// Export the clips of the selected query
+		m.openExport()
+
+	case key.Matches(msg, k.Delete):
+		// Delete the selected video from the library
+		m.openDeleteVideo()
+
+	case key.Matches(msg, k.Search):
+		// Search every indexed video
+		cmds = append(cmds, m.openSearch())
+
+	case key.Matches(msg, k.Jobs):
+		// Open the background jobs panel
+		m.viewMode = JobsView
+
+	case key.Matches(msg, k.Logs):
+		// Open the log viewer
+		m.openLogs()
+
+	case key.Matches(msg, k.Errors):
+		// Open the error center
+		m.openNotices()
+
+	case key.Matches(msg, k.Menu):
 		// Open menu
 		m.viewMode = MenuView
 		m.updateMenuList()
 
-	case "?":
+	case key.Matches(msg, k.Palette):
+		// Open the command palette
+		cmds = append(cmds, m.openPalette())
+
+	case key.Matches(msg, k.Help):
 		// Show help
 		m.viewMode = HelpView
 
-	case "u":
+	case key.Matches(msg, k.Upload):
 		// Start upload dialog
-		m.viewMode = UploadDialogView
-		m.uploadFocus = 0
-		m.uploadTitleInput.Reset()
-		m.uploadTitleInput.Focus()
-		m.uploadURLInput.Reset()
-		m.uploadURLInput.Blur()
+		m.openUpload()
 		return m, nil
-	case "up", "k":
+	case key.Matches(msg, k.Up):
 		// Navigate up in active section or scroll details
 		if m.activeSection == StatusSection {
 			// Scroll details view up
@@ -302,18 +530,10 @@ func (m Model) updateMainView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			m.detailsView.SetYOffset(newOffset)
 		} else {
-			switch m.activeSection {
-			case LibrarySection:
-				m.libraryList, _ = m.libraryList.Update(msg)
-				m.updateSelectedVideo()
-			case HistorySection:
-				m.historyList, _ = m.historyList.Update(msg)
-				m.updateSelectedQuery()
-			}
-			m.updateDetailView()
+			cmds = append(cmds, m.updateSectionList(m.activeSection, msg))
 		}
 
-	case "down", "j":
+	case key.Matches(msg, k.Down):
 		// Navigate down in active section or scroll details
 		if m.activeSection == StatusSection {
 			// Scroll details view down
@@ -326,18 +546,10 @@ func (m Model) updateMainView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			m.detailsView.SetYOffset(newOffset)
 		} else {
-			switch m.activeSection {
-			case LibrarySection:
-				m.libraryList, _ = m.libraryList.Update(msg)
-				m.updateSelectedVideo()
-			case HistorySection:
-				m.historyList, _ = m.historyList.Update(msg)
-				m.updateSelectedQuery()
-			}
-			m.updateDetailView()
+			cmds = append(cmds, m.updateSectionList(m.activeSection, msg))
 		}
 
-	case "enter":
+	case key.Matches(msg, k.Select):
 		// Select item
 		switch m.activeSection {
 		case LibrarySection:
@@ -355,20 +567,31 @@ func (m Model) updateMainView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) updateQuestionDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
-	switch msg.String() {
-	case "esc":
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
 		m.viewMode = MainView
+		m.batchAsk = false
 		return m, nil
 
-	case "ctrl+s":
-		// Submit question
+	case key.Matches(msg, m.keys.Template):
+		// Pick a question from the prompt library
+		return m, m.openTemplatePicker()
+
+	case key.Matches(msg, m.keys.Submit, m.keys.SubmitRefresh):
+		// Submit question; SubmitRefresh asks the API even if the answer is cached
 		question := m.questionInput.Value()
+		refresh := key.Matches(msg, m.keys.SubmitRefresh)
 		if question != "" {
-			// Close dialog immediately and show spinner
+			// Close dialog immediately; the answer arrives in the background
 			m.viewMode = MainView
-			m.isLoading = true
-			m.statusMessage = "Asking question..."
-			cmds = append(cmds, m.askQuestion(question))
+			if m.batchAsk {
+				m.batchAsk = false
+				cmds = append(cmds, m.startBatch(question, refresh))
+				m.viewMode = BatchView
+			} else {
+				m.statusMessage = "Question queued"
+				cmds = append(cmds, m.askQuestion(question, refresh))
+			}
 		}
 		return m, tea.Batch(cmds...)
 	}
@@ -385,12 +608,12 @@ func (m Model) updateQuestionDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) updateUploadDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
-	switch msg.String() {
-	case "esc":
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
 		m.viewMode = MainView
 		return m, nil
 
-	case "tab":
+	case key.Matches(msg, m.keys.NextField):
 		// Switch focus to next field
 		m.uploadFocus = (m.uploadFocus + 1) % 2
 		if m.uploadFocus == 0 {
@@ -402,7 +625,7 @@ func (m Model) updateUploadDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case "shift+tab", "btab":
+	case key.Matches(msg, m.keys.PrevField):
 		// Switch focus to previous field
 		m.uploadFocus = (m.uploadFocus - 1 + 2) % 2
 		if m.uploadFocus == 0 {
@@ -414,14 +637,13 @@ func (m Model) updateUploadDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case "enter":
+	case key.Matches(msg, m.keys.UploadSubmit):
 		// Submit upload
 		title := m.uploadTitleInput.Value()
 		url := m.uploadURLInput.Value()
 		if title != "" && url != "" {
 			m.viewMode = MainView
-			m.isLoading = true
-			m.statusMessage = "Uploading video..."
+			m.statusMessage = "Upload queued"
 			cmds = append(cmds, m.uploadVideo(title, url))
 		}
 		return m, tea.Batch(cmds...)
@@ -443,34 +665,20 @@ func (m Model) updateUploadDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) updateMenuView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	if key.Matches(msg, m.keys.Menu) {
+		m.viewMode = MainView
+		return m, nil
+	}
+
 	switch msg.String() {
-	case "esc", "x":
+	case "esc":
 		m.viewMode = MainView
 		return m, nil
 
 	case "enter":
 		// Execute menu action
-		selected := m.menuList.SelectedItem()
-		if item, ok := selected.(menuItem); ok {
-			switch item.action {
-			case "quit":
-				return m, tea.Quit
-			case "help":
-				m.viewMode = HelpView
-			case "about":
-				m.viewMode = AboutView
-			case "ask":
-				if m.selectedVideo != nil {
-					m.viewMode = QuestionDialogView
-					m.questionInput.Reset()
-					m.questionInput.Focus()
-				}
-			case "refresh":
-				m.isLoading = true
-				m.statusMessage = "Refreshing..."
-				cmds = append(cmds, m.refreshLibrary())
-				m.viewMode = MainView
-			}
+		if item, ok := m.menuList.SelectedItem().(menuItem); ok {
+			cmds = append(cmds, m.runCommand(item.action))
 		}
 		return m, tea.Batch(cmds...)
 	}
@@ -485,7 +693,7 @@ func (m Model) updateMenuView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // updateHelpView handles input in the help view
 func (m Model) updateHelpView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	if key.Matches(msg, key.NewBinding(key.WithKeys("esc", "q", "?"))) {
+	if key.Matches(msg, key.NewBinding(key.WithKeys("esc", "q")), m.keys.Help) {
 		m.viewMode = MainView
 	}
 	return m, nil
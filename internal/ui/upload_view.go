@@ -2,7 +2,7 @@ package ui
 
 import "github.com/charmbracelet/lipgloss"
 
-var focusedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("69")).Bold(true)
+var focusedStyle = lipgloss.NewStyle().Bold(true)
 
 // viewUploadDialog renders the upload input dialog
 func (m Model) viewUploadDialog() string {
@@ -26,7 +26,7 @@ func (m Model) viewUploadDialog() string {
 		lipgloss.JoinHorizontal(lipgloss.Top, titleLabel, " ", titleInput),
 		lipgloss.JoinHorizontal(lipgloss.Top, urlLabel, " ", urlInput),
 		"",
-		footerStyle.Render("tab/shift+tab: switch, enter: upload, esc: cancel"),
+		footerStyle.Render(joinHelp(m.keys.uploadHelp()...)),
 	)
 	dialog := dialogStyle.Render(content)
 	return lipgloss.Place(
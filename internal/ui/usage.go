@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// usageRefresh is how often the API call ledger is read again; other
+// be-my-eyes processes write to it too
+const usageRefresh = 5 * time.Second
+
+// usageLoadedMsg is sent when today's API calls are read from the ledger
+type usageLoadedMsg struct {
+	calls map[string]int // per endpoint
+	err   error
+}
+
+// usageTickMsg asks for the ledger to be read again
+type usageTickMsg struct{}
+
+// loadUsage reads today's API calls from the ledger
+func (m Model) loadUsage() tea.Cmd {
+	database := m.database
+	if database == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		calls, err := database.GetAPICalls(time.Now())
+		return usageLoadedMsg{calls: calls, err: err}
+	}
+}
+
+// usageTick schedules the next ledger read
+func usageTick() tea.Cmd {
+	return tea.Tick(usageRefresh, func(time.Time) tea.Msg { return usageTickMsg{} })
+}
+
+// requestsToday returns the number of API calls made today
+func (m Model) requestsToday() int {
+	total := 0
+	for _, n := range m.usage {
+		total += n
+	}
+	return total
+}
+
+// usageLine returns the request count of the Status section, with a warning
+// once the configured share of the daily quota is used
+func (m Model) usageLine() string {
+	if m.usage == nil {
+		return ""
+	}
+	total := m.requestsToday()
+	quota := m.config.API.DailyQuota
+	if quota == 0 {
+		return fmt.Sprintf("%d requests today", total)
+	}
+
+	line := fmt.Sprintf("%d requests today / limit %d", total, quota)
+	switch {
+	case total >= quota:
+		return "⚠ " + line + " - quota reached"
+	case total*100 >= quota*m.config.API.QuotaWarning:
+		return "⚠ " + line + " - quota almost reached"
+	}
+	return line
+}
+
+// renderUsage lists today's API calls per endpoint for the details panel
+func (m Model) renderUsage() string {
+	if len(m.usage) == 0 {
+		return ""
+	}
+	endpoints := make([]string, 0, len(m.usage))
+	for endpoint := range m.usage {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	var b strings.Builder
+	b.WriteString("API calls today:\n\n")
+	for _, endpoint := range endpoints {
+		limit := ""
+		if rate := m.config.RateLimits().Endpoints[endpoint]; rate.Requests > 0 {
+			limit = fmt.Sprintf(" (limited to %s)", rate)
+		} else if rate := m.config.RateLimits().Default; rate.Requests > 0 {
+			limit = fmt.Sprintf(" (limited to %s)", rate)
+		}
+		b.WriteString(fmt.Sprintf("  %-8s %d%s\n", endpoint, m.usage[endpoint], limit))
+	}
+	return b.String()
+}
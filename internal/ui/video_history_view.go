@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// toggleHistoryScope switches the History section between all videos and the
+// selected video only
+func (m *Model) toggleHistoryScope() tea.Cmd {
+	if !m.historyScoped && m.selectedVideo == nil {
+		m.statusMessage = "Select a video to scope the history"
+		return nil
+	}
+
+	m.historyScoped = !m.historyScoped
+	m.historyList.ResetSelected()
+	if !m.historyScoped {
+		return m.updateHistoryList()
+	}
+
+	videoID := m.selectedVideo.VideoID
+	if videoID != m.videoHistoryID {
+		m.videoHistoryID = videoID
+		m.videoHistory = nil
+	}
+	return tea.Batch(m.updateHistoryList(), m.loadVideoHistory(videoID))
+}
+
+// openVideoHistory opens the Q&A timeline of the selected video
+func (m *Model) openVideoHistory() tea.Cmd {
+	if m.selectedVideo == nil {
+		return nil
+	}
+
+	videoID := m.selectedVideo.VideoID
+	if videoID != m.videoHistoryID {
+		m.videoHistoryID = videoID
+		m.videoHistory = nil
+	}
+	m.viewMode = VideoHistoryView
+	m.refreshVideoHistoryView()
+	m.videoHistoryView.GotoTop()
+	return m.loadVideoHistory(videoID)
+}
+
+// refreshVideoHistoryView sizes the timeline viewport and renders its content
+func (m *Model) refreshVideoHistoryView() {
+	width := m.width - 10
+	if width < 20 {
+		width = 20
+	}
+	height := m.height - 10
+	if height < 5 {
+		height = 5
+	}
+	m.videoHistoryView.Width = width
+	m.videoHistoryView.Height = height
+
+	content := lipgloss.NewStyle().Width(width).Render(m.renderVideoHistory())
+	m.videoHistoryView.SetContent(content)
+}
+
+// renderVideoHistory renders every saved Q&A of the video, oldest first
+func (m Model) renderVideoHistory() string {
+	if len(m.videoHistory) == 0 {
+		return "No questions asked about this video yet."
+	}
+
+	var b strings.Builder
+	// History is stored newest first; the timeline reads oldest first
+	for i := len(m.videoHistory) - 1; i >= 0; i-- {
+		q := m.videoHistory[i]
+
+		b.WriteString(statusStyle.Render(q.CreatedAt.Local().Format("2006-01-02 15:04")))
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("Q: %s\n", q.Question))
+
+		if q.Error != nil && *q.Error != "" {
+			b.WriteString(fmt.Sprintf("Error: %s\n", *q.Error))
+		} else {
+			b.WriteString(fmt.Sprintf("A: %s\n", q.Answer))
+		}
+
+		b.WriteString(m.renderClips(q))
+
+		if i > 0 {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+// viewVideoHistory renders the Q&A timeline of a video
+func (m Model) viewVideoHistory() string {
+	title := "Video Q&A"
+	for _, v := range m.videos {
+		if v.VideoID == m.videoHistoryID {
+			title += ": " + videoItem{video: v}.Title()
+			break
+		}
+	}
+	title += fmt.Sprintf(" (%d)", len(m.videoHistory))
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render(title),
+		"",
+		m.videoHistoryView.View(),
+		"",
+		footerStyle.Render("↑↓/pgup/pgdn: scroll, esc: back"),
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogStyle.Render(content),
+	)
+}
+
+// updateVideoHistoryView handles input in the video Q&A timeline
+func (m Model) updateVideoHistoryView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, key.NewBinding(key.WithKeys("esc", "q")), m.keys.VideoHistory) {
+		m.viewMode = MainView
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.videoHistoryView, cmd = m.videoHistoryView.Update(msg)
+	return m, cmd
+}
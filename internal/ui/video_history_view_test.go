@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+func TestRenderVideoHistoryOldestFirst(t *testing.T) {
+	day := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	failure := "video not indexed"
+	m := Model{videoHistory: []models.QueryHistory{ // newest first, as loaded
+		{Question: "Who left?", Answer: "The cat.", CreatedAt: day.Add(2 * time.Hour),
+			VideoClips: []models.VideoClip{{StartTime: 1.5, EndTime: 3, Info: "The cat leaves"}}},
+		{Question: "Anyone there?", Error: &failure, CreatedAt: day.Add(time.Hour)},
+		{Question: "Who came in?", Answer: "A dog.", CreatedAt: day},
+	}}
+
+	got := m.renderVideoHistory()
+	order := []string{"Q: Who came in?", "A: A dog.", "Q: Anyone there?", "Error: video not indexed", "Q: Who left?", "A: The cat.", "1.5s - 3.0s The cat leaves"}
+	last := -1
+	for _, want := range order {
+		i := strings.Index(got, want)
+		if i < 0 {
+			t.Fatalf("timeline is missing %q:\n%s", want, got)
+		}
+		if i < last {
+			t.Fatalf("%q is out of order, want oldest first:\n%s", want, got)
+		}
+		last = i
+	}
+
+	if got := (Model{}).renderVideoHistory(); !strings.Contains(got, "No questions") {
+		t.Errorf("empty timeline = %q", got)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/fboucher/be-my-eyes/internal/config"
+	"github.com/muesli/termenv"
 )
 
 // Styles
@@ -41,6 +43,25 @@ var (
 			Padding(1, 2)
 )
 
+// applyTheme sets the colors of the shared styles. Without colors, the
+// active box has a thick border and colors are turned off everywhere.
+func applyTheme(theme config.ThemeConfig) {
+	boxStyle = boxStyle.BorderForeground(color(theme.Border))
+	activeBoxStyle = activeBoxStyle.BorderForeground(color(theme.Accent)).Border(lipgloss.RoundedBorder())
+	titleStyle = titleStyle.Foreground(color(theme.Title))
+	statusStyle = statusStyle.Foreground(color(theme.Accent))
+	footerStyle = footerStyle.Foreground(color(theme.Muted))
+	dialogStyle = dialogStyle.BorderForeground(color(theme.Accent))
+	focusedStyle = focusedStyle.Foreground(color(theme.Focus))
+
+	if theme.Colorless() {
+		activeBoxStyle = activeBoxStyle.Border(lipgloss.ThickBorder())
+		lipgloss.SetColorProfile(termenv.Ascii)
+	} else {
+		lipgloss.SetColorProfile(terminalProfile)
+	}
+}
+
 // View renders the TUI
 func (m Model) View() string {
 	switch m.viewMode {
@@ -54,6 +75,30 @@ func (m Model) View() string {
 		return m.viewAbout()
 	case UploadDialogView:
 		return m.viewUploadDialog()
+	case VideoHistoryView:
+		return m.viewVideoHistory()
+	case JobsView:
+		return m.viewJobs()
+	case BatchView:
+		return m.viewBatch()
+	case TemplatePickerView:
+		return m.viewTemplatePicker()
+	case ExportDialogView:
+		return m.viewExportDialog()
+	case SetupView:
+		return m.viewSetup()
+	case ProfilePickerView:
+		return m.viewProfilePicker()
+	case DeleteDialogView:
+		return m.viewDeleteDialog()
+	case LogView:
+		return m.viewLogs()
+	case NoticesView:
+		return m.viewNotices()
+	case PaletteView:
+		return m.viewPalette()
+	case SearchView:
+		return m.viewSearch()
 	default:
 		return m.viewMain()
 	}
@@ -65,8 +110,8 @@ func (m Model) viewMain() string {
 		return "Loading..."
 	}
 
-	// Calculate dimensions (40-60 split)
-	leftWidth := int(float64(m.width) * 0.4)
+	// Calculate dimensions (40-60 split by default)
+	leftWidth := int(float64(m.width) * m.config.UI.LayoutSplit)
 	rightWidth := m.width - leftWidth - 4 // Account for borders and padding
 
 	// Build left column
@@ -97,6 +142,7 @@ func (m Model) renderLeftColumn(width int) string {
 	historyHeight := remainingHeight - libraryHeight
 
 	// Library section
+	m.libraryList = withMatchCount(m.libraryList, "Videos")
 	m.libraryList.SetSize(width-6, libraryHeight-2)
 	libraryContent := m.libraryList.View()
 	libraryStyle := boxStyle
@@ -106,6 +152,7 @@ func (m Model) renderLeftColumn(width int) string {
 	libraryBox := libraryStyle.Width(width - 4).Height(libraryHeight).Render(libraryContent)
 
 	// History section
+	m.historyList = withMatchCount(m.historyList, m.historyTitle())
 	m.historyList.SetSize(width-6, historyHeight-2)
 	historyContent := m.historyList.View()
 	historyStyle := boxStyle
@@ -141,7 +188,17 @@ func (m Model) renderStatus() string {
 	if m.isLoading {
 		status = m.spinner.View() + " " + status
 	}
-	return titleStyle.Render("Status") + "\n" + statusStyle.Render(status)
+	content := titleStyle.Render("Status") + footerStyle.Render(" • "+m.profileLabel()) + "\n" + statusStyle.Render(status)
+	if jobs := m.jobs.summary(); jobs != "" {
+		content += "\n" + statusStyle.Render(m.spinner.View()+" "+jobs)
+	}
+	if usage := m.usageLine(); usage != "" {
+		content += "\n" + statusStyle.Render(usage)
+	}
+	if badge := m.noticeBadge(); badge != "" {
+		content += "\n" + statusStyle.Render(badge)
+	}
+	return content
 }
 
 // renderDetails renders the details panel based on what's selected
@@ -160,7 +217,14 @@ func (m Model) renderDetails() string {
 		return "Select a query to see details"
 
 	default:
-		return "No details available"
+		var sections []string
+		if usage := m.renderUsage(); usage != "" {
+			sections = append(sections, usage)
+		}
+		if len(sections) == 0 {
+			return "No details available"
+		}
+		return strings.Join(sections, "\n")
 	}
 }
 
@@ -173,6 +237,7 @@ func (m Model) renderVideoDetails() string {
 	b.WriteString(fmt.Sprintf("ID: %s\n", v.VideoID))
 	b.WriteString(fmt.Sprintf("Status: %s\n", strings.ToUpper(v.IndexingStatus)))
 	b.WriteString(fmt.Sprintf("Duration: %.1fs\n\n", v.Metadata.Duration))
+	b.WriteString(m.renderSearchMatch(v))
 
 	if v.Metadata.Description != "" {
 		b.WriteString(fmt.Sprintf("Description:\n%s\n\n", v.Metadata.Description))
@@ -190,6 +255,11 @@ func (m Model) renderQueryDetails() string {
 	q := m.selectedQuery
 	var b strings.Builder
 
+	b.WriteString(fmt.Sprintf("Query #%d • %s\n\n", q.ID, q.VideoTitle))
+	if m.cachedQueries[q.ID] {
+		b.WriteString(focusedStyle.Render(fmt.Sprintf("⚡ Cached answer from %s, not asked again", q.CreatedAt.Local().Format("2006-01-02 15:04"))))
+		b.WriteString("\n\n")
+	}
 	b.WriteString("Question:\n")
 	b.WriteString(q.Question)
 	b.WriteString("\n\n")
@@ -203,27 +273,27 @@ func (m Model) renderQueryDetails() string {
 		b.WriteString(q.Answer)
 	}
 
+	if len(q.VideoClips) > 0 {
+		b.WriteString("\n\nClips:\n")
+		b.WriteString(m.renderClips(*q))
+	}
+
 	return b.String()
 }
 
 // renderFooter renders the footer with key bindings
 func (m Model) renderFooter() string {
-	keys := []string{
-		"u: upload",
-		"r: refresh",
-		"a: ask question",
-		"x: menu",
-		"q: quit",
-		"tab: change section",
-		"↑↓: navigate/scroll",
-	}
-	return footerStyle.Render(strings.Join(keys, ", "))
+	h := m.help
+	h.Width = m.width
+	return h.ShortHelpView(m.keys.ShortHelp())
 }
 
 // viewQuestionDialog renders the question input dialog
 func (m Model) viewQuestionDialog() string {
 	title := "Ask a Question"
-	if m.selectedVideo != nil {
+	if m.batchAsk {
+		title += fmt.Sprintf(" (%d selected videos)", len(m.markedVideos))
+	} else if m.selectedVideo != nil {
 		title += fmt.Sprintf(" (Video: %s)", m.selectedVideo.Metadata.Title)
 	}
 
@@ -233,7 +303,7 @@ func (m Model) viewQuestionDialog() string {
 		"",
 		m.questionInput.View(),
 		"",
-		footerStyle.Render("ctrl+s: submit, esc: cancel"),
+		footerStyle.Render(joinHelp(m.keys.questionHelp()...)),
 	)
 
 	dialog := dialogStyle.Render(content)
@@ -267,28 +337,27 @@ func (m Model) viewMenu() string {
 
 // viewHelp renders the help screen
 func (m Model) viewHelp() string {
-	help := `Be My Eyes - Help
-
-Navigation:
-  ↑/↓, j/k    - Navigate lists
-  tab         - Switch between sections
-  enter       - Select item
-
-Actions:
-  r           - Refresh library
-  a           - Ask a question about selected video
-  u           - Upload video (not yet implemented)
-  x           - Open menu
-  ?           - Show this help
-  q           - Quit
-
-Question Dialog:
-  ctrl+s      - Submit question
-  esc         - Cancel
-
-Press any key to return to main view.`
+	groups := m.keys.FullHelp()
+	left := lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.helpSection("Navigation", groups[0]),
+		footerStyle.Render(`"between 14:00 and 14:30" as a History filter`),
+		footerStyle.Render("keeps the clips at that time of day"),
+		"",
+		m.helpSection("Question Dialog", m.keys.questionHelp()),
+		"",
+		m.helpSection("Upload Dialog", m.keys.uploadHelp()),
+	)
+	right := m.helpSection("Actions", groups[1])
 
-	content := helpStyle.Render(help)
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render("Be My Eyes - Help"),
+		"",
+		lipgloss.JoinHorizontal(lipgloss.Top, left, "    ", right),
+		"",
+		footerStyle.Render("Keys can be changed with the ui.keybindings settings. esc: back"),
+	)
 	return lipgloss.Place(
 		m.width,
 		m.height,
@@ -0,0 +1,160 @@
+// Package wallclock turns clip offsets into times of day for videos that
+// record when they started, such as security-camera footage, and filters the
+// history by time of day.
+package wallclock
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+// day is the length of the time-of-day circle
+const day = 24 * time.Hour
+
+// Start returns when a video started, in loc, if the video records it
+func Start(metadata models.VideoMetadata, loc *time.Location) (time.Time, bool) {
+	if metadata.VideoStartTimestampUTCMs == nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(*metadata.VideoStartTimestampUTCMs).In(loc), true
+}
+
+// Starts returns the start time of every video that records it, by video ID
+func Starts(videos []models.Video, loc *time.Location) map[string]time.Time {
+	starts := make(map[string]time.Time)
+	for _, v := range videos {
+		if start, ok := Start(v.Metadata, loc); ok {
+			starts[v.VideoID] = start
+		}
+	}
+	return starts
+}
+
+// At returns the wall-clock time of an offset in seconds from start
+func At(start time.Time, offset float64) time.Time {
+	return start.Add(time.Duration(offset * float64(time.Second)))
+}
+
+// Format formats a wall-clock time as HH:MM:SS
+func Format(t time.Time) string {
+	return t.Format("15:04:05")
+}
+
+// Span is the wall-clock span of a clip
+type Span struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ClipSpan returns the wall-clock span of a clip of a video started at start
+func ClipSpan(start time.Time, clip models.VideoClip) Span {
+	return Span{Start: At(start, clip.StartTime), End: At(start, clip.EndTime)}
+}
+
+// String formats a span as HH:MM:SS-HH:MM:SS
+func (s Span) String() string {
+	return Format(s.Start) + "-" + Format(s.End)
+}
+
+// Range is a time-of-day range, as offsets from midnight. From after To
+// wraps past midnight.
+type Range struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// rangePattern matches "between 14:00 and 14:30", "from 14:00 to 14:30" and
+// "14:00-14:30"
+var rangePattern = regexp.MustCompile(`(?i)^\s*(?:(?:between|from)\s+)?(\d{1,2}:\d{2}(?::\d{2})?)\s*(?:-|and|to)\s*(\d{1,2}:\d{2}(?::\d{2})?)\s*$`)
+
+// ParseRange parses a time-of-day range filter. ok is false when the text
+// isn't a range, so it can be searched as plain text instead.
+func ParseRange(s string) (r Range, ok bool, err error) {
+	match := rangePattern.FindStringSubmatch(s)
+	if match == nil {
+		return Range{}, false, nil
+	}
+
+	if r.From, err = parseTimeOfDay(match[1]); err != nil {
+		return Range{}, true, err
+	}
+	if r.To, err = parseTimeOfDay(match[2]); err != nil {
+		return Range{}, true, err
+	}
+	return r, true, nil
+}
+
+// parseTimeOfDay parses HH:MM or HH:MM:SS as an offset from midnight
+func parseTimeOfDay(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	limits := []int{23, 59, 59}
+	var offset time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		if i >= len(parts) {
+			break
+		}
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n > limits[i] {
+			return 0, fmt.Errorf("invalid time of day %q", s)
+		}
+		offset += time.Duration(n) * unit
+	}
+	return offset, nil
+}
+
+// sinceMidnight returns the time of day of t as an offset from midnight
+func sinceMidnight(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+}
+
+// Overlaps reports whether a span shares a time of day with the range. Spans
+// of a day or more match any range.
+func (r Range) Overlaps(s Span) bool {
+	if s.End.Sub(s.Start) >= day {
+		return true
+	}
+
+	// Both intervals are unrolled so they never wrap, then compared on the
+	// previous, same and next day
+	from, to := r.From, r.To
+	if to < from {
+		to += day
+	}
+	start := sinceMidnight(s.Start)
+	end := start + s.End.Sub(s.Start)
+	for _, shift := range []time.Duration{-day, 0, day} {
+		if start+shift <= to && end+shift >= from {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether any clip of a query, from a video started at
+// start, overlaps the range
+func (r Range) Matches(q models.QueryHistory, start time.Time) bool {
+	for _, clip := range q.VideoClips {
+		if r.Overlaps(ClipSpan(start, clip)) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterHistory keeps the queries with a clip in the range. Queries about
+// videos without a start time never match.
+func FilterHistory(history []models.QueryHistory, starts map[string]time.Time, r Range) []models.QueryHistory {
+	var matches []models.QueryHistory
+	for _, q := range history {
+		if start, ok := starts[q.VideoID]; ok && r.Matches(q, start) {
+			matches = append(matches, q)
+		}
+	}
+	return matches
+}
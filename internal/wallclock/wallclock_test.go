@@ -0,0 +1,138 @@
+package wallclock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fboucher/be-my-eyes/internal/models"
+)
+
+func hm(h, m int) time.Duration {
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Range
+		ok      bool
+		wantErr bool
+	}{
+		{"between 14:00 and 14:30", Range{hm(14, 0), hm(14, 30)}, true, false},
+		{"From 9:05 TO 9:10", Range{hm(9, 5), hm(9, 10)}, true, false},
+		{"14:00-14:30:15", Range{hm(14, 0), hm(14, 30) + 15*time.Second}, true, false},
+		{" 23:30 - 00:15 ", Range{hm(23, 30), hm(0, 15)}, true, false},
+		{"24:00-01:00", Range{}, true, true},
+		{"10:60-11:00", Range{}, true, true},
+		{"who rang at 14:00", Range{}, false, false},
+		{"14:00", Range{}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, ok, err := ParseRange(tt.text)
+			if ok != tt.ok || (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRange() ok = %v, err = %v, want ok %v, wantErr %v", ok, err, tt.ok, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRange() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	day := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	span := func(h, m int, length time.Duration) Span {
+		start := day.Add(hm(h, m))
+		return Span{Start: start, End: start.Add(length)}
+	}
+
+	tests := []struct {
+		name string
+		r    Range
+		span Span
+		want bool
+	}{
+		{"inside", Range{hm(14, 0), hm(14, 30)}, span(14, 10, time.Minute), true},
+		{"touches the end", Range{hm(14, 0), hm(14, 30)}, span(14, 30, time.Minute), true},
+		{"before", Range{hm(14, 0), hm(14, 30)}, span(13, 0, time.Minute), false},
+		{"covers the range", Range{hm(14, 0), hm(14, 30)}, span(13, 0, 2*time.Hour), true},
+		{"range past midnight, late span", Range{hm(23, 0), hm(1, 0)}, span(23, 30, time.Minute), true},
+		{"range past midnight, early span", Range{hm(23, 0), hm(1, 0)}, span(0, 30, time.Minute), true},
+		{"range past midnight, midday span", Range{hm(23, 0), hm(1, 0)}, span(12, 0, time.Minute), false},
+		{"span past midnight", Range{hm(0, 0), hm(0, 10)}, span(23, 55, 10*time.Minute), true},
+		{"span past midnight, range before it", Range{hm(22, 0), hm(23, 0)}, span(23, 55, 10*time.Minute), false},
+		{"day-long span", Range{hm(3, 0), hm(3, 1)}, span(4, 0, 24*time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Overlaps(tt.span); got != tt.want {
+				t.Errorf("Overlaps(%s) = %v, want %v", tt.span, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterHistory(t *testing.T) {
+	// 2026-03-14 13:00 UTC: 09:00 in Toronto, 22:00 in Tokyo
+	startMs := time.Date(2026, 3, 14, 13, 0, 0, 0, time.UTC).UnixMilli()
+	videos := []models.Video{
+		{VideoID: "cam", Metadata: models.VideoMetadata{VideoStartTimestampUTCMs: &startMs}},
+		{VideoID: "upload"}, // no start time
+	}
+	history := []models.QueryHistory{
+		{ID: 1, VideoID: "cam", VideoClips: []models.VideoClip{{StartTime: 600, EndTime: 660}}},         // +10 min
+		{ID: 2, VideoID: "cam", VideoClips: []models.VideoClip{{StartTime: 7200 + 600, EndTime: 8000}}}, // +2h10
+		{ID: 3, VideoID: "cam"}, // no clips
+		{ID: 4, VideoID: "upload", VideoClips: []models.VideoClip{{StartTime: 0, EndTime: 86400}}},
+	}
+
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+
+	tests := []struct {
+		name string
+		loc  *time.Location
+		text string
+		want []int
+	}{
+		{"morning in Toronto", toronto, "09:00-09:30", []int{1}},
+		{"same times in Tokyo", tokyo, "09:00-09:30", nil},
+		{"past midnight in Tokyo", tokyo, "between 23:30 and 00:30", []int{2}},
+		{"whole evening in Tokyo", tokyo, "from 21:00 to 01:00", []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _, err := ParseRange(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for _, q := range FilterHistory(history, Starts(videos, tt.loc), r) {
+				got = append(got, q.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("FilterHistory() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("FilterHistory() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestClipSpan(t *testing.T) {
+	start := time.Date(2026, 3, 14, 23, 59, 30, 0, time.UTC)
+	got := ClipSpan(start, models.VideoClip{StartTime: 15.5, EndTime: 45}).String()
+	if got != "23:59:45-00:00:15" {
+		t.Errorf("ClipSpan() = %s, want 23:59:45-00:00:15", got)
+	}
+}